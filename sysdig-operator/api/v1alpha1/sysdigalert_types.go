@@ -0,0 +1,104 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SysdigAlertSpec defines a Monitor alert of the team that owns the
+// namespace. Exactly one of PromQL and Metric must be set.
+type SysdigAlertSpec struct {
+	// Name of the alert in Sysdig. Defaults to the name of the SysdigAlert.
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// +kubebuilder:validation:Enum=high;medium;low;info
+	// +kubebuilder:default=medium
+	Severity string `json:"severity,omitempty"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+	// PromQL alerts fire when the query returns a result.
+	PromQL *PromQLAlertSpec `json:"promql,omitempty"`
+	// Metric alerts compare a Sysdig metric with a threshold.
+	Metric *MetricAlertSpec `json:"metric,omitempty"`
+	// Duration is how long the condition must hold before the alert fires.
+	Duration metav1.Duration `json:"duration"`
+	// NotificationChannels are names from spec.team.notificationChannels
+	// of the SysdigTeam in this namespace.
+	NotificationChannels []string `json:"notificationChannels,omitempty"`
+}
+
+// PromQLAlertSpec is a PromQL alert condition.
+type PromQLAlertSpec struct {
+	// Query is a PromQL expression. Every series selector must be limited
+	// to namespaces of the team with a kube_namespace_name matcher, e.g.
+	// sum(kube_pod_container_status_restarts_total{kube_namespace_name=~"abc123-dev|abc123-prod"}) > 5.
+	Query string `json:"query"`
+}
+
+// MetricAlertSpec is a threshold on a Sysdig metric, e.g.
+// {metric: cpu.used.percent, aggregation: avg, operator: ">", threshold: "80"}.
+type MetricAlertSpec struct {
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_.]+$`
+	Metric string `json:"metric"`
+	// +kubebuilder:validation:Enum=avg;max;min;sum
+	// +kubebuilder:default=avg
+	Aggregation string `json:"aggregation,omitempty"`
+	// +kubebuilder:validation:Enum=">";">=";"<";"<=";"=";"!="
+	Operator string `json:"operator"`
+	// +kubebuilder:validation:Pattern=`^-?[0-9]+(\.[0-9]+)?$`
+	Threshold string `json:"threshold"`
+}
+
+// SysdigAlertStatus defines the observed state of SysdigAlert
+type SysdigAlertStatus struct {
+	// ID is the Sysdig alert ID.
+	ID int64 `json:"id,omitempty"`
+	// TeamID is the Monitor team the alert was created in.
+	TeamID int64 `json:"teamID,omitempty"`
+	// ObservedGeneration is the generation last synced to Sysdig.
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	Conditions         []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sysdig-alerts
+// +kubebuilder:printcolumn:name="ID",type=integer,JSONPath=`.status.id`
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+
+// SysdigAlert is the Schema for the sysdig-alerts API
+type SysdigAlert struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SysdigAlertSpec   `json:"spec,omitempty"`
+	Status SysdigAlertStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SysdigAlertList contains a list of SysdigAlert
+type SysdigAlertList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SysdigAlert `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SysdigAlert{}, &SysdigAlertList{})
+}
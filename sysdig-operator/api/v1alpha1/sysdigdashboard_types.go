@@ -0,0 +1,93 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SysdigDashboardSpec defines a Monitor dashboard of the team that owns the
+// namespace. Exactly one of JSON and ConfigMapRef must be set.
+type SysdigDashboardSpec struct {
+	// Name of the dashboard in Sysdig. Defaults to the name in the JSON,
+	// then to the name of the SysdigDashboard.
+	Name string `json:"name,omitempty"`
+	// JSON is the dashboard as exported from Sysdig, bare or wrapped in
+	// {"dashboard": ...}. Its id, version and teamId are ignored.
+	JSON string `json:"json,omitempty"`
+	// ConfigMapRef reads the dashboard JSON from a ConfigMap in this namespace.
+	ConfigMapRef *corev1.ConfigMapKeySelector `json:"configMapRef,omitempty"`
+	// Sharing controls who else can see the dashboard. By default the
+	// whole team can read it.
+	Sharing *DashboardSharingSpec `json:"sharing,omitempty"`
+}
+
+// DashboardSharingSpec is the sharing of a dashboard.
+type DashboardSharingSpec struct {
+	// TeamRole is what the members of the team may do with the dashboard.
+	// "none" keeps it private to its owner.
+	// +kubebuilder:validation:Enum=none;read;edit
+	// +kubebuilder:default=read
+	TeamRole string `json:"teamRole,omitempty"`
+	// Public publishes the dashboard to anyone with its link.
+	Public bool `json:"public,omitempty"`
+}
+
+// SysdigDashboardStatus defines the observed state of SysdigDashboard
+type SysdigDashboardStatus struct {
+	// ID is the Sysdig dashboard ID.
+	ID int64 `json:"id,omitempty"`
+	// Version is the Sysdig version of the dashboard after the last sync.
+	Version int64 `json:"version,omitempty"`
+	// TeamID is the Monitor team the dashboard was created in.
+	TeamID int64 `json:"teamID,omitempty"`
+	// ContentHash is the hash of the dashboard last sent to Sysdig.
+	ContentHash string `json:"contentHash,omitempty"`
+	// ObservedGeneration is the generation last synced to Sysdig.
+	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
+	Conditions         []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=sysdig-dashboards
+// +kubebuilder:printcolumn:name="ID",type=integer,JSONPath=`.status.id`
+// +kubebuilder:printcolumn:name="Version",type=integer,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Synced",type=string,JSONPath=`.status.conditions[?(@.type=="Synced")].status`
+
+// SysdigDashboard is the Schema for the sysdig-dashboards API
+type SysdigDashboard struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SysdigDashboardSpec   `json:"spec,omitempty"`
+	Status SysdigDashboardStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SysdigDashboardList contains a list of SysdigDashboard
+type SysdigDashboardList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SysdigDashboard `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SysdigDashboard{}, &SysdigDashboardList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,8 +31,179 @@ type SysdigTeamGoSpec struct {
 
 // TeamSpec holds the team‐level settings from the CR
 type TeamSpec struct {
-	Description string     `json:"description,omitempty"`
-	Users       []UserSpec `json:"users,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Users are added to every enabled product team.
+	Users []UserSpec `json:"users,omitempty"`
+	// Monitor configures the <prefix>-team Monitor team.
+	Monitor *ProductSpec `json:"monitor,omitempty"`
+	// Secure configures the <prefix>-team-secure Secure team.
+	Secure *ProductSpec `json:"secure,omitempty"`
+	// Namespaces adjusts the default <prefix>-tools/-dev/-test/-prod scope of the teams.
+	Namespaces *NamespaceSpec `json:"namespaces,omitempty"`
+	// Scopes narrows the Monitor and Secure team scopes with extra clauses.
+	Scopes *ScopeSpec `json:"scopes,omitempty"`
+	// Membership adds users from OpenShift Groups and namespace RoleBindings
+	// to every enabled product team. Users listed in spec.team.users keep
+	// the role given there.
+	Membership *MembershipSpec `json:"membership,omitempty"`
+	// ServiceAccounts are Sysdig team service accounts whose API tokens are
+	// written to Secrets in this namespace, e.g. for CI.
+	ServiceAccounts []ServiceAccountSpec `json:"serviceAccounts,omitempty"`
+	// NotificationChannels are created for the Monitor team only.
+	NotificationChannels []NotificationChannelSpec `json:"notificationChannels,omitempty"`
+	// Dashboards are names from the operator's dashboard catalog to create
+	// in the Monitor team, e.g. resource-allocation. Empty means the
+	// platform defaults.
+	Dashboards []string `json:"dashboards,omitempty"`
+	// ResourceQuotas names the ResourceQuotas that catalog dashboards chart
+	// usage against. Unset names are those of the platform project sets.
+	ResourceQuotas *ResourceQuotaSpec `json:"resourceQuotas,omitempty"`
+}
+
+// ResourceQuotaSpec names the ResourceQuotas of the team namespaces.
+type ResourceQuotaSpec struct {
+	// Compute is the CPU and memory quota, e.g. compute-long-running-quota.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	Compute string `json:"compute,omitempty"`
+	// Storage is the storage quota, e.g. storage-quota.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	Storage string `json:"storage,omitempty"`
+}
+
+// NotificationChannelSpec is one Monitor team notification channel.
+type NotificationChannelSpec struct {
+	// Name is unique among the channels of the team.
+	Name string `json:"name"`
+	// +kubebuilder:validation:Enum=email;slack;msTeams;webhook
+	Type string `json:"type"`
+	// Enabled defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Recipients are the addresses of an email channel.
+	Recipients []string `json:"recipients,omitempty"`
+	// URL is the webhook URL of a slack, msTeams or webhook channel.
+	// Prefer URLSecretRef, webhook URLs usually embed a secret.
+	URL string `json:"url,omitempty"`
+	// URLSecretRef reads the webhook URL from a Secret in this namespace.
+	// Changes to the Secret are picked up right away only when it has the
+	// label app.kubernetes.io/managed-by: sysdig-operator.
+	URLSecretRef *corev1.SecretKeySelector `json:"urlSecretRef,omitempty"`
+	// SlackChannel is the Slack channel to post to, e.g. "#abc123-alerts".
+	SlackChannel string `json:"slackChannel,omitempty"`
+}
+
+// ServiceAccountSpec asks for one team service account. Its token is
+// replaced before it expires, and the account is deleted in Sysdig when the
+// entry is removed.
+type ServiceAccountSpec struct {
+	// Name is unique per product.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+	// Product is the team the account belongs to.
+	// +kubebuilder:validation:Enum=monitor;secure
+	// +kubebuilder:default=monitor
+	Product string `json:"product,omitempty"`
+	// Role is the team role of the account, e.g. ROLE_TEAM_READ.
+	Role string `json:"role"`
+	// SecretName defaults to sysdig-<product>-<name>.
+	SecretName string `json:"secretName,omitempty"`
+	// Lifetime is how long each token is valid. Defaults to 90 days.
+	Lifetime *metav1.Duration `json:"lifetime,omitempty"`
+	// RotateAfter replaces the token on a schedule, before it expires.
+	// Defaults to two thirds of Lifetime.
+	RotateAfter *metav1.Duration `json:"rotateAfter,omitempty"`
+}
+
+// MembershipSpec lists where team users are derived from. Memberships are
+// re-synced whenever a referenced Group or a RoleBinding in the team
+// namespaces changes.
+type MembershipSpec struct {
+	// Groups are user.openshift.io Groups whose users join the teams.
+	Groups []GroupSource `json:"groups,omitempty"`
+	// RoleBindings, when set, adds the users and groups bound to a mapped
+	// role in the team namespaces.
+	RoleBindings *RoleBindingSource `json:"roleBindings,omitempty"`
+	// EmailDomain turns usernames that are not email addresses into one,
+	// e.g. "jdoe@azureidir" becomes "jdoe@<emailDomain>". Without it those
+	// users are skipped.
+	EmailDomain string `json:"emailDomain,omitempty"`
+}
+
+// GroupSource gives the users of an OpenShift Group a Sysdig team role.
+type GroupSource struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// RoleBindingSource derives users from RoleBindings.
+type RoleBindingSource struct {
+	// RoleMapping maps the name of the bound ClusterRole to a Sysdig team
+	// role. Defaults to admin: ROLE_TEAM_EDIT, edit: ROLE_TEAM_EDIT and
+	// view: ROLE_TEAM_READ. Bindings to other roles, and to namespaced
+	// Roles, are ignored.
+	RoleMapping map[string]string `json:"roleMapping,omitempty"`
+}
+
+// ProductSpec holds the settings of one product team.
+type ProductSpec struct {
+	// Enabled defaults to true. Disabling a product deletes its team.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Description overrides spec.team.description for this team.
+	Description string `json:"description,omitempty"`
+	// Users are only added to this team. A user also listed in
+	// spec.team.users gets the role given here.
+	Users []UserSpec `json:"users,omitempty"`
+	// UI sets the landing page and theme of the team.
+	UI *UISpec `json:"ui,omitempty"`
+	// Permissions turns additional team permissions on or off, e.g.
+	// hasAgentCli: false. Permissions the platform policy does not allow
+	// stay off; the effective set is reported in status.
+	Permissions map[string]bool `json:"permissions,omitempty"`
+}
+
+// UISpec holds the UI settings of a team.
+type UISpec struct {
+	// EntryPoint is the module the team lands on.
+	EntryPoint *EntryPointSpec `json:"entryPoint,omitempty"`
+	// Theme is the team colour, e.g. "#73A1F7".
+	// +kubebuilder:validation:Pattern=`^#[0-9A-Fa-f]{6}$`
+	Theme string `json:"theme,omitempty"`
+}
+
+// EntryPointSpec is a Sysdig UI module, e.g. {module: Dashboards}.
+type EntryPointSpec struct {
+	Module    string `json:"module"`
+	Selection string `json:"selection,omitempty"`
+}
+
+// NamespaceSpec customises the set of namespaces the Sysdig teams can see.
+// Only namespaces of the same project set (sharing the <prefix>- of the
+// -tools namespace) are accepted, and they must exist in the cluster.
+type NamespaceSpec struct {
+	// Extra namespaces to add, e.g. abc123-sandbox.
+	Extra []string `json:"extra,omitempty"`
+	// Excluded namespaces to remove, including any of the four defaults.
+	Excluded []string `json:"excluded,omitempty"`
+	// Selector adds every namespace of the project set whose labels match.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// ScopeSpec holds extra scope clauses per product. Each clause is ANDed with
+// the namespace clause, and its label must be on the operator's allowlist.
+type ScopeSpec struct {
+	Monitor []ScopeClause `json:"monitor,omitempty"`
+	Secure  []ScopeClause `json:"secure,omitempty"`
+}
+
+// ScopeClause is one condition of a team scope, e.g.
+// {label: kubernetes.cluster.name, operator: in, values: [silver]}.
+type ScopeClause struct {
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_./-]+$`
+	Label string `json:"label"`
+	// +kubebuilder:validation:Enum=in;notIn;equals;notEquals;contains;notContains;startsWith
+	Operator string `json:"operator"`
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
 }
 
 // UserSpec represents one entry in spec.team.users
@@ -47,6 +219,58 @@ type SysdigTeamStatus struct {
 	MonitorTeamID int64       `json:"monitorTeamID,omitempty"`
 	SecureTeamID  int64       `json:"secureTeamID,omitempty"`
 	Conditions    []Condition `json:"conditions,omitempty"`
+	// Namespaces is the effective namespace scope of the teams.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ScopedNamespaces are the namespaces this cluster last wrote into the
+	// agent scope of its teams. With a cluster name, they are what it takes
+	// out of a scope shared with other clusters before adding its own.
+	ScopedNamespaces []string `json:"scopedNamespaces,omitempty"`
+	// Monitor and Secure report the effective settings of each product team.
+	Monitor *ProductStatus `json:"monitor,omitempty"`
+	Secure  *ProductStatus `json:"secure,omitempty"`
+	// ServiceAccounts are the team service accounts the operator created.
+	ServiceAccounts []ServiceAccountStatus `json:"serviceAccounts,omitempty"`
+	// NotificationChannels maps the channels of the spec to their Sysdig IDs.
+	NotificationChannels []NotificationChannelStatus `json:"notificationChannels,omitempty"`
+	// Dashboards are the catalog dashboards of the Monitor team.
+	Dashboards []DashboardStatus `json:"dashboards,omitempty"`
+}
+
+// DashboardStatus records which template version a catalog dashboard was
+// rendered from.
+type DashboardStatus struct {
+	Template string `json:"template"`
+	ID       int64  `json:"id"`
+	Version  int    `json:"version"`
+}
+
+// NotificationChannelStatus is a notification channel created by the operator.
+type NotificationChannelStatus struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+}
+
+// ServiceAccountStatus records a created service account and when its
+// token is next rotated.
+type ServiceAccountStatus struct {
+	Name       string `json:"name"`
+	Product    string `json:"product"`
+	Role       string `json:"role"`
+	TeamID     int64  `json:"teamID"`
+	ID         int64  `json:"id"`
+	SecretName string `json:"secretName"`
+	// ExpiresAt is when the current token stops working.
+	ExpiresAt metav1.Time `json:"expiresAt"`
+	// RotateAt is when the token is replaced.
+	RotateAt metav1.Time `json:"rotateAt"`
+}
+
+// ProductStatus is what the platform policy let through of a ProductSpec.
+type ProductStatus struct {
+	UI          UISpec          `json:"ui,omitempty"`
+	Permissions map[string]bool `json:"permissions,omitempty"`
+	// Clamped lists the requested settings the policy overrode.
+	Clamped []string `json:"clamped,omitempty"`
 }
 
 // +kubebuilder:object:root=true
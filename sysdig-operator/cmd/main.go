@@ -17,18 +17,25 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -38,6 +45,7 @@ import (
 	"github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
 	opsv1alpha1 "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
 	"github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/controller"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -60,6 +68,19 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	rateLimits := helpers.DefaultRateLimitConfig()
+	var cacheConfig helpers.CacheConfig
+	timeouts := helpers.TimeoutConfig{Default: helpers.DefaultOperationTimeout}
+	var operationTimeouts string
+	var checkEndpoints bool
+	auditing := helpers.DefaultAuditConfig()
+	var scopeAllowlist string
+	var clusterName string
+	var teamPolicyPath string
+	var defaultDashboards string
+	dashboardRollout := helpers.DefaultDashboardRollout()
+	var dashboardPins string
+	var teamTokens helpers.TeamTokenConfig
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -70,6 +91,50 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.Float64Var(&rateLimits.ReadsPerSecond, "sysdig-read-qps", rateLimits.ReadsPerSecond,
+		"Sustained rate of read (GET) calls to the Sysdig API, shared by all reconciles. 0 disables the limit.")
+	flag.IntVar(&rateLimits.ReadBurst, "sysdig-read-burst", rateLimits.ReadBurst,
+		"Burst size for read calls to the Sysdig API.")
+	flag.Float64Var(&rateLimits.WritesPerSecond, "sysdig-write-qps", rateLimits.WritesPerSecond,
+		"Sustained rate of write (POST/PUT/DELETE) calls to the Sysdig API, shared by all reconciles. 0 disables the limit.")
+	flag.IntVar(&rateLimits.WriteBurst, "sysdig-write-burst", rateLimits.WriteBurst,
+		"Burst size for write calls to the Sysdig API.")
+	flag.DurationVar(&cacheConfig.TTL, "sysdig-cache-ttl", 10*time.Minute,
+		"How long the shared Sysdig user and team cache is trusted. 0 disables the cache.")
+	flag.DurationVar(&cacheConfig.RefreshInterval, "sysdig-cache-refresh-interval", 5*time.Minute,
+		"How often every Sysdig user and team is reloaded into the cache.")
+	flag.DurationVar(&cacheConfig.MissTTL, "sysdig-cache-miss-ttl", 30*time.Second,
+		"How long a Sysdig user or team that was not found is remembered as missing. 0 disables it.")
+	flag.DurationVar(&timeouts.Default, "sysdig-api-timeout", timeouts.Default,
+		"Timeout for a single Sysdig API operation, including retries. Listings apply it per page.")
+	flag.StringVar(&operationTimeouts, "sysdig-operation-timeouts", "",
+		"Per-operation overrides of --sysdig-api-timeout, e.g. CreateDashboard=30s,FetchUsers=20s.")
+	flag.BoolVar(&checkEndpoints, "sysdig-check-endpoints", true,
+		"If set, the operator exits at startup when a Sysdig API endpoint is not reachable.")
+	flag.IntVar(&auditing.Verbosity, "sysdig-audit-verbosity", auditing.Verbosity,
+		"Log verbosity of the per-request Sysdig API audit log. 0 logs every request at info level.")
+	flag.BoolVar(&auditing.LogBodies, "sysdig-audit-bodies", false,
+		"If set, redacted Sysdig API request and response bodies are logged at debug level.")
+	flag.StringVar(&scopeAllowlist, "sysdig-scope-allowlist", strings.Join(helpers.DefaultScopeAllowlist, ","),
+		"Comma-separated labels tenants may use in spec.team.scopes. A trailing * allows every label with that prefix.")
+	flag.StringVar(&clusterName, "sysdig-cluster-name", os.Getenv("SYSDIG_CLUSTER_NAME"),
+		"The kubernetes.cluster.name of this cluster in Sysdig. When set, team scopes are limited to this cluster "+
+			"and merged with the clusters of operators elsewhere that manage the same team.")
+	flag.StringVar(&teamPolicyPath, "sysdig-team-policy", "",
+		"Path to a YAML file limiting the team UI settings and permissions tenants may set, "+
+			"e.g. a mounted ConfigMap. Unset uses the built-in policy.")
+	flag.StringVar(&defaultDashboards, "sysdig-default-dashboards", strings.Join(helpers.DefaultDashboards, ","),
+		"Comma-separated catalog dashboards created for Monitor teams whose spec.team.dashboards is empty. "+
+			"An empty value creates none.")
+	flag.IntVar(&dashboardRollout.Percent, "sysdig-dashboard-rollout-percent", dashboardRollout.Percent,
+		"Percentage of existing teams whose catalog dashboards are upgraded in place to the latest template version.")
+	flag.StringVar(&dashboardPins, "sysdig-dashboard-versions", "",
+		"Comma-separated name=version pins of catalog dashboards for every team, e.g. resources-approve=1 to roll back.")
+	flag.StringVar(&teamTokens.User, "sysdig-team-token-user", os.Getenv("SYSDIG_TEAM_TOKEN_USER"),
+		"Username of the platform service account whose team-scoped tokens are used for dashboard, alert and "+
+			"notification channel requests. It is added to teams it is missing from. Unset uses SYSDIG_TOKEN for everything.")
+	flag.StringVar(&teamTokens.Role, "sysdig-team-token-role", helpers.DefaultTeamTokenRole,
+		"Team role the --sysdig-team-token-user is added to teams with.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -79,6 +144,12 @@ func main() {
 	// ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
+	var err error
+	if timeouts.Operations, err = helpers.ParseOperationTimeouts(operationTimeouts); err != nil {
+		setupLog.Error(err, "invalid --sysdig-operation-timeouts")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -140,17 +211,111 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		// LeaderElectionReleaseOnCancel: true,
+
+		// Only Secrets labelled for the operator are cached and watched.
+		Cache: cache.Options{ByObject: map[client.Object]cache.ByObject{
+			&corev1.Secret{}: {Label: controller.ManagedSecretSelector()},
+		}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	// Build the Sysdig API client once and share it across all reconciles.
+	// Without credentials the reconciler reports a SysdigCredentials condition instead.
+	// The endpoints are derived from SYSDIG_REGION (us1, us2, eu1, au1 or on-prem);
+	// SYSDIG_API_ENDPOINT, SYSDIG_DASHBOARD_API_ENDPOINT and SYSDIG_SECURE_API_ENDPOINT
+	// override the platform, Monitor and Secure URLs of the region.
+	var sysdigClient helpers.SysdigAPI
+	region := os.Getenv("SYSDIG_REGION")
+	apiEndpoint := os.Getenv("SYSDIG_API_ENDPOINT")
+	token := os.Getenv("SYSDIG_TOKEN")
+	if (region != "" || apiEndpoint != "") && token != "" {
+		endpoints, err := helpers.ResolveEndpoints(region, helpers.Endpoints{
+			Monitor:  os.Getenv("SYSDIG_DASHBOARD_API_ENDPOINT"),
+			Secure:   os.Getenv("SYSDIG_SECURE_API_ENDPOINT"),
+			Platform: apiEndpoint,
+		})
+		if err != nil {
+			setupLog.Error(err, "unable to resolve Sysdig endpoints")
+			os.Exit(1)
+		}
+		setupLog.Info("Resolved Sysdig endpoints", "region", region,
+			"monitor", endpoints.Monitor, "secure", endpoints.Secure, "platform", endpoints.Platform)
+
+		baseClient := helpers.NewSysdigClientForEndpoints(endpoints, token).
+			WithRateLimit(rateLimits).
+			WithTimeouts(timeouts).
+			WithAuditLog(auditing).
+			WithTeamTokens(teamTokens)
+		if checkEndpoints {
+			if err := endpoints.CheckReachable(context.Background(), &http.Client{}); err != nil {
+				setupLog.Error(err, "Sysdig endpoints are not reachable")
+				os.Exit(1)
+			}
+		}
+		sysdigClient = baseClient
+		if cacheConfig.TTL > 0 {
+			cachingClient := helpers.NewCachingClient(baseClient, cacheConfig)
+			if err := mgr.Add(cachingClient); err != nil {
+				setupLog.Error(err, "unable to add Sysdig cache refresher")
+				os.Exit(1)
+			}
+			sysdigClient = cachingClient
+		}
+	} else {
+		setupLog.Error(nil, "SYSDIG_REGION or SYSDIG_API_ENDPOINT, and SYSDIG_TOKEN, are not set, Sysdig client disabled")
+	}
+
+	teamPolicy := helpers.DefaultTeamPolicy()
+	if teamPolicyPath != "" {
+		if teamPolicy, err = helpers.LoadTeamPolicy(teamPolicyPath); err != nil {
+			setupLog.Error(err, "unable to load team policy")
+			os.Exit(1)
+		}
+	}
+
+	dashboards, err := helpers.ParseDashboardNames(defaultDashboards)
+	if err != nil {
+		setupLog.Error(err, "unable to parse --sysdig-default-dashboards")
+		os.Exit(1)
+	}
+	if dashboardRollout.Pins, err = helpers.ParseDashboardPins(dashboardPins); err != nil {
+		setupLog.Error(err, "unable to parse --sysdig-dashboard-versions")
+		os.Exit(1)
+	}
+
 	if err = (&controller.SysdigTeamGoReconciler{
+		Client:            mgr.GetClient(),
+		Scheme:            mgr.GetScheme(),
+		APIReader:         mgr.GetAPIReader(),
+		Sysdig:            sysdigClient,
+		ScopeAllowlist:    helpers.ParseScopeAllowlist(scopeAllowlist),
+		ClusterName:       clusterName,
+		TeamPolicy:        &teamPolicy,
+		DefaultDashboards: dashboards,
+		DashboardRollout:  &dashboardRollout,
+		TeamTokenUser:     teamTokens.User,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SysdigTeamGo")
+		os.Exit(1)
+	}
+	if err = (&controller.SysdigAlertReconciler{
+		Client:      mgr.GetClient(),
+		Scheme:      mgr.GetScheme(),
+		Sysdig:      sysdigClient,
+		ClusterName: clusterName,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SysdigAlert")
+		os.Exit(1)
+	}
+	if err = (&controller.SysdigDashboardReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Sysdig: sysdigClient,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "SysdigTeamGo")
+		setupLog.Error(err, "unable to create controller", "controller", "SysdigDashboard")
 		os.Exit(1)
 	}
 	// +kubebuilder:scaffold:builder
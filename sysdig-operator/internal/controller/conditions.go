@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// unauthorizedRequeueAfter spaces out retries while the operator token is rejected.
+	unauthorizedRequeueAfter = 5 * time.Minute
+	// throttledRequeueAfter is used when Sysdig throttles us without a Retry-After.
+	throttledRequeueAfter = time.Minute
+	// namespaceRecheckAfter re-validates spec.team.namespaces that named missing namespaces.
+	namespaceRecheckAfter = 5 * time.Minute
+)
+
+// invalidSpecError reports a spec that cannot be sent to Sysdig as it is.
+// Reconcilers record it in a condition and do not requeue, since the spec
+// has to change first.
+type invalidSpecError struct {
+	reasons []string
+}
+
+func (e *invalidSpecError) Error() string {
+	return strings.Join(e.reasons, "; ")
+}
+
+// classifySysdigError maps a Sysdig API error onto a condition reason and
+// decides how the request is requeued. fallbackReason is used for errors
+// that do not need special handling; those are returned so controller-runtime
+// requeues them with its usual backoff.
+func classifySysdigError(err error, fallbackReason string) (string, ctrl.Result, error) {
+	switch {
+	case helpers.IsUnauthorized(err):
+		// A bad token will not fix itself on the next attempt.
+		return "Unauthorized", ctrl.Result{RequeueAfter: unauthorizedRequeueAfter}, nil
+	case helpers.IsThrottled(err):
+		after := throttledRequeueAfter
+		if apiErr, ok := helpers.AsAPIError(err); ok && apiErr.RetryAfter > 0 {
+			after = apiErr.RetryAfter
+		}
+		return "Throttled", ctrl.Result{RequeueAfter: after}, nil
+	case helpers.IsUnprocessable(err), helpers.IsBadRequest(err):
+		// Sysdig rejected the payload; retrying the same spec will fail again.
+		return "InvalidRequest", ctrl.Result{}, nil
+	case helpers.IsAmbiguous(err):
+		// Several Sysdig objects share the name or email; an admin has to clean up.
+		return "AmbiguousMatch", ctrl.Result{}, nil
+	case helpers.IsServerError(err):
+		return "SysdigUnavailable", ctrl.Result{}, err
+	}
+	return fallbackReason, ctrl.Result{}, err
+}
+
+// failReconcile records a Ready=False condition for a failed Sysdig call and
+// returns the result the reconcile loop should hand back to controller-runtime.
+func (r *SysdigTeamGoReconciler) failReconcile(
+	ctx context.Context,
+	sysdigTeam *api.SysdigTeam,
+	fallbackReason, message string,
+	err error,
+) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	reason, result, retErr := classifySysdigError(err, fallbackReason)
+
+	logger.Error(err, message, "reason", reason)
+	sysdigTeam.Status.Conditions = []api.Condition{
+		{Type: "Ready", Status: "False", Reason: reason, Message: message + ": " + err.Error()},
+	}
+	if statusUpdateErr := r.Status().Update(ctx, sysdigTeam); statusUpdateErr != nil {
+		logger.Error(statusUpdateErr, "Failed to update status", "reason", reason)
+	}
+	return result, retErr
+}
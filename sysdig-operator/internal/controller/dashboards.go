@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// teamDashboards returns the catalog dashboards of a team: the ones in
+// spec.team.dashboards, or the platform defaults when it lists none.
+func (r *SysdigTeamGoReconciler) teamDashboards(names []string) ([]string, error) {
+	if len(names) == 0 {
+		if r.DefaultDashboards == nil {
+			return helpers.DefaultDashboards, nil
+		}
+		return r.DefaultDashboards, nil
+	}
+
+	var invalid []string
+	seen := map[string]bool{}
+	var out []string
+	for _, name := range names {
+		if _, err := helpers.LookupDashboardTemplate(name); err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, &invalidSpecError{reasons: invalid}
+	}
+	return out, nil
+}
+
+// dashboardsInScope checks that the team scope has the namespaces the
+// dashboards render. Dashboards listed in the spec that need the -prod
+// namespace fail without it; default dashboards are skipped instead, since
+// the spec cannot opt out of them.
+func dashboardsInScope(ctx context.Context, names []string, facts helpers.TeamFacts, listed bool) ([]string, error) {
+	if facts.ProdNamespace != "" {
+		return names, nil
+	}
+	var invalid []string
+	var out []string
+	for _, name := range names {
+		t, err := helpers.LookupDashboardTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(t.Variables, "ProdNamespace") {
+			out = append(out, name)
+			continue
+		}
+		if listed {
+			invalid = append(invalid, fmt.Sprintf("dashboard %s needs namespace %s-prod, which is not in the team scope", name, facts.NSPrefix))
+			continue
+		}
+		log.FromContext(ctx).Info("Skipping default dashboard, the team scope has no prod namespace", "dashboard", name)
+	}
+	if len(invalid) > 0 {
+		return nil, &invalidSpecError{reasons: invalid}
+	}
+	return out, nil
+}
+
+// dashboardContext returns what the catalog templates of a team render
+// with. spec.team.resourceQuotas overrides the quota names of the facts.
+func (r *SysdigTeamGoReconciler) dashboardContext(facts helpers.TeamFacts, quotas *api.ResourceQuotaSpec) helpers.DashboardContext {
+	if quotas != nil {
+		if quotas.Compute != "" {
+			facts.ComputeQuota = quotas.Compute
+		}
+		if quotas.Storage != "" {
+			facts.StorageQuota = quotas.Storage
+		}
+	}
+	return helpers.NewDashboardContext(facts, r.ClusterName)
+}
+
+// dashboardRollout returns the platform's dashboard rollout settings.
+func (r *SysdigTeamGoReconciler) dashboardRollout() helpers.DashboardRollout {
+	if r.DashboardRollout == nil {
+		return helpers.DefaultDashboardRollout()
+	}
+	return *r.DashboardRollout
+}
+
+// syncDashboards makes sure the Monitor team has each catalog dashboard in
+// names, at the template version the rollout gives the team. Dashboards are
+// found by ID from status, by marker or by name, so dashboards created
+// before they were tracked are adopted instead of duplicated. Missing ones
+// are created and outdated ones upgraded in place. Dashboards removed from
+// names are left in Sysdig but no longer tracked.
+func (r *SysdigTeamGoReconciler) syncDashboards(
+	ctx context.Context,
+	team *api.SysdigTeam,
+	names []string,
+	data helpers.DashboardContext,
+) error {
+	logger := log.FromContext(ctx)
+	if team.Status.MonitorTeamID == 0 {
+		team.Status.Dashboards = nil
+		return nil
+	}
+	data.TeamID = team.Status.MonitorTeamID
+	ctx = helpers.WithTeam(ctx, data.TeamID)
+	rollout := r.dashboardRollout()
+
+	existing, err := r.Sysdig.ListDashboards(ctx, data.TeamID)
+	if err != nil {
+		return fmt.Errorf("list dashboards of team %d: %w", data.TeamID, err)
+	}
+
+	var tracked []api.DashboardStatus
+	var errs []error
+	for _, name := range names {
+		s, found, err := findDashboard(team.Status.Dashboards, existing, name, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		target := rollout.Target(data.TeamName, name, s.Version)
+
+		switch {
+		case !found:
+			t, err := helpers.LookupDashboardTemplateVersion(name, target)
+			var d *helpers.Dashboard
+			if err == nil {
+				d, err = r.Sysdig.CreateDashboard(ctx, t, data)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("create dashboard %s: %w", name, err))
+				continue
+			}
+			logger.Info("Created dashboard for team", "teamID", data.TeamID, "dashboard", name, "version", target)
+			s = api.DashboardStatus{Template: name, ID: d.ID, Version: target}
+		case target != s.Version:
+			current, err := r.Sysdig.GetDashboard(ctx, s.ID)
+			if err == nil {
+				err = r.upgradeDashboard(ctx, current, name, target, data)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("upgrade dashboard %s from version %d to %d: %w", name, s.Version, target, err))
+				tracked = append(tracked, s)
+				continue
+			}
+			logger.Info("Upgraded dashboard", "dashboard", name, "ID", s.ID, "from", s.Version, "to", target)
+			s.Version = target
+		}
+		tracked = append(tracked, s)
+	}
+	team.Status.Dashboards = tracked
+	return errors.Join(errs...)
+}
+
+// findDashboard looks for the catalog dashboard name among the team's
+// dashboards: by the ID in status, then by marker, then by the name the
+// template gives it. A dashboard without a marker predates template
+// versions and counts as version 1, so the rollout decides when it is
+// upgraded.
+func findDashboard(
+	status []api.DashboardStatus,
+	existing []helpers.Dashboard,
+	name string,
+	data helpers.DashboardContext,
+) (api.DashboardStatus, bool, error) {
+	found := func(d helpers.Dashboard) (api.DashboardStatus, bool, error) {
+		s := api.DashboardStatus{Template: name, ID: d.ID, Version: 1}
+		if marked, version, ok := helpers.ParseDashboardMarker(d.Description); ok && marked == name {
+			s.Version = version
+		}
+		return s, true, nil
+	}
+
+	for _, s := range status {
+		if s.Template != name {
+			continue
+		}
+		for _, d := range existing {
+			if d.ID == s.ID {
+				return found(d)
+			}
+		}
+	}
+	for _, d := range existing {
+		if marked, _, ok := helpers.ParseDashboardMarker(d.Description); ok && marked == name {
+			return found(d)
+		}
+	}
+
+	t, err := helpers.LookupDashboardTemplate(name)
+	if err != nil {
+		return api.DashboardStatus{}, false, err
+	}
+	doc, err := t.Document(data)
+	if err != nil {
+		return api.DashboardStatus{}, false, err
+	}
+	for _, d := range existing {
+		if d.Name == doc.Name {
+			return found(d)
+		}
+	}
+	return api.DashboardStatus{}, false, nil
+}
+
+// sooner returns the shorter of two requeue delays, where 0 means none.
+func sooner(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// upgradeDashboard replaces a dashboard with another version of its
+// template. The ID stays, so links keep working, and the sharing and
+// favourite settings users made in Sysdig are kept.
+func (r *SysdigTeamGoReconciler) upgradeDashboard(
+	ctx context.Context,
+	current *helpers.Dashboard,
+	name string,
+	version int,
+	data helpers.DashboardContext,
+) error {
+	t, err := helpers.LookupDashboardTemplateVersion(name, version)
+	if err != nil {
+		return err
+	}
+	d, err := t.Document(data)
+	if err != nil {
+		return err
+	}
+	d.ID, d.Version = current.ID, current.Version
+	d.Public, d.SharingSettings = current.Public, current.SharingSettings
+	d.KeepFields(current, "favorite")
+	_, err = r.Sysdig.UpdateDashboard(ctx, d)
+	return err
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// groupGVK is the OpenShift Group. It is read as unstructured so the
+// operator also runs on clusters without the user.openshift.io API.
+var groupGVK = schema.GroupVersionKind{Group: "user.openshift.io", Version: "v1", Kind: "Group"}
+
+// defaultRoleMapping is used when spec.team.membership.roleBindings has no roleMapping.
+// Namespace admins get ROLE_TEAM_EDIT, as managers are never pruned by
+// syncMemberships and would keep their membership after losing the binding.
+var defaultRoleMapping = map[string]string{
+	"admin": "ROLE_TEAM_EDIT",
+	"edit":  "ROLE_TEAM_EDIT",
+	"view":  "ROLE_TEAM_READ",
+}
+
+// roleRank orders Sysdig team roles, so a user found through several
+// sources gets the strongest of them.
+var roleRank = map[string]int{
+	"ROLE_TEAM_READ":     1,
+	"ROLE_TEAM_STANDARD": 2,
+	"ROLE_TEAM_EDIT":     3,
+	"ROLE_TEAM_MANAGER":  4,
+}
+
+// membershipUsers derives team users from the Groups and RoleBindings named
+// in spec, sorted by email. RoleBindings are read in the team namespaces.
+func (r *SysdigTeamGoReconciler) membershipUsers(
+	ctx context.Context,
+	spec *api.MembershipSpec,
+	namespaces []string,
+) ([]api.UserSpec, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	logger := log.FromContext(ctx)
+
+	roles := map[string]string{}
+	add := func(username, role string) {
+		email, ok := membershipEmail(username, spec.EmailDomain)
+		if !ok {
+			logger.V(1).Info("Skipping member without an email address", "user", username)
+			return
+		}
+		if current, ok := roles[email]; !ok || roleRank[role] > roleRank[current] {
+			roles[email] = role
+		}
+	}
+	addGroup := func(name, role string) error {
+		users, err := r.groupUsers(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, u := range users {
+			add(u, role)
+		}
+		return nil
+	}
+
+	for _, g := range spec.Groups {
+		if err := addGroup(g.Name, g.Role); err != nil {
+			return nil, err
+		}
+	}
+
+	if spec.RoleBindings != nil {
+		mapping := spec.RoleBindings.RoleMapping
+		if len(mapping) == 0 {
+			mapping = defaultRoleMapping
+		}
+		for _, ns := range namespaces {
+			var list rbacv1.RoleBindingList
+			if err := r.List(ctx, &list, client.InNamespace(ns)); err != nil {
+				return nil, fmt.Errorf("list rolebindings in %s: %w", ns, err)
+			}
+			for _, rb := range list.Items {
+				// Tenants can create Roles of any name in their namespaces,
+				// so only ClusterRoles are mapped.
+				if rb.RoleRef.Kind != "ClusterRole" {
+					continue
+				}
+				role, ok := mapping[rb.RoleRef.Name]
+				if !ok {
+					continue
+				}
+				for _, subject := range rb.Subjects {
+					if strings.HasPrefix(subject.Name, "system:") {
+						continue
+					}
+					switch subject.Kind {
+					case rbacv1.UserKind:
+						add(subject.Name, role)
+					case rbacv1.GroupKind:
+						if err := addGroup(subject.Name, role); err != nil {
+							return nil, err
+						}
+					}
+				}
+			}
+		}
+	}
+
+	emails := make([]string, 0, len(roles))
+	for email := range roles {
+		emails = append(emails, email)
+	}
+	sort.Strings(emails)
+	users := make([]api.UserSpec, 0, len(emails))
+	for _, email := range emails {
+		users = append(users, api.UserSpec{Name: email, Role: roles[email]})
+	}
+	return users, nil
+}
+
+// teamTokenUserID returns the Sysdig ID of TeamTokenUser, or 0 when it is
+// not set or does not exist.
+func (r *SysdigTeamGoReconciler) teamTokenUserID(ctx context.Context) (int64, error) {
+	if r.TeamTokenUser == "" {
+		return 0, nil
+	}
+	user, err := r.Sysdig.FindUserByEmail(ctx, r.TeamTokenUser)
+	if err != nil || user == nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// groupUsers returns the users of an OpenShift Group. A missing Group, or a
+// cluster without Groups, has no users.
+func (r *SysdigTeamGoReconciler) groupUsers(ctx context.Context, name string) ([]string, error) {
+	group := &unstructured.Unstructured{}
+	group.SetGroupVersionKind(groupGVK)
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, group); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			log.FromContext(ctx).Info("Group not found, it adds no team members", "group", name)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get group %s: %w", name, err)
+	}
+	users, _, err := unstructured.NestedStringSlice(group.Object, "users")
+	if err != nil {
+		return nil, fmt.Errorf("read users of group %s: %w", name, err)
+	}
+	return users, nil
+}
+
+// membershipEmail maps an OpenShift username onto a Sysdig email. Usernames
+// that are already email addresses are kept; others get domain, if any.
+func membershipEmail(username, domain string) (string, bool) {
+	username = strings.ToLower(strings.TrimSpace(username))
+	if strings.HasPrefix(username, "system:") {
+		return "", false
+	}
+	local, host, _ := strings.Cut(username, "@")
+	if local == "" {
+		return "", false
+	}
+	if strings.Contains(host, ".") {
+		return username, true
+	}
+	if domain = strings.ToLower(strings.TrimPrefix(domain, "@")); domain == "" {
+		return "", false
+	}
+	return local + "@" + domain, true
+}
+
+// teamsForRoleBinding maps a RoleBinding onto the SysdigTeams that derive
+// members from RoleBindings in its namespace.
+func (r *SysdigTeamGoReconciler) teamsForRoleBinding(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.teamsMatching(ctx, func(team *api.SysdigTeam) bool {
+		m := team.Spec.Team.Membership
+		return m != nil && m.RoleBindings != nil && slices.Contains(team.Status.Namespaces, obj.GetNamespace())
+	})
+}
+
+// teamsForGroup maps a Group onto the SysdigTeams that reference it. Teams
+// using RoleBindings are included too, as a binding may name the Group.
+func (r *SysdigTeamGoReconciler) teamsForGroup(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.teamsMatching(ctx, func(team *api.SysdigTeam) bool {
+		m := team.Spec.Team.Membership
+		if m == nil {
+			return false
+		}
+		return m.RoleBindings != nil || slices.ContainsFunc(m.Groups, func(g api.GroupSource) bool {
+			return g.Name == obj.GetName()
+		})
+	})
+}
+
+func (r *SysdigTeamGoReconciler) teamsMatching(ctx context.Context, match func(*api.SysdigTeam) bool) []reconcile.Request {
+	var teams api.SysdigTeamList
+	if err := r.List(ctx, &teams); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list SysdigTeams for a membership change")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range teams.Items {
+		if match(&teams.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&teams.Items[i])})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// resolveNamespaces computes the namespaces the teams are scoped to: the
+// default project set namespaces plus spec extras and selector matches,
+// minus exclusions. Namespaces outside the project set are rejected and
+// extra namespaces must exist. Default namespaces that do not exist are left
+// out, unless they are in the current scope: those are kept, so a namespace
+// that is briefly gone does not narrow the teams, and returned as missing.
+func (r *SysdigTeamGoReconciler) resolveNamespaces(
+	ctx context.Context,
+	facts helpers.TeamFacts,
+	spec *api.NamespaceSpec,
+	current []string,
+) (namespaces, missing []string, err error) {
+	logger := log.FromContext(ctx)
+	if spec == nil {
+		spec = &api.NamespaceSpec{}
+	}
+
+	prefix := facts.NSPrefix + "-"
+	excluded := map[string]bool{}
+	for _, ns := range spec.Excluded {
+		excluded[strings.ToLower(ns)] = true
+	}
+
+	var invalid []string
+	seen := map[string]bool{}
+	add := func(ns string) {
+		if !seen[ns] && !excluded[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+
+	for _, ns := range facts.Namespaces {
+		exists, err := r.namespaceExists(ctx, ns)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			if excluded[ns] || !containsString(current, ns) {
+				logger.V(1).Info("Default namespace does not exist, leaving it out of the team scope", "namespace", ns)
+				continue
+			}
+			logger.Info("Default namespace does not exist, keeping it in the team scope", "namespace", ns)
+			missing = append(missing, ns)
+		}
+		add(ns)
+	}
+
+	for _, ns := range spec.Extra {
+		ns = strings.ToLower(strings.TrimSpace(ns))
+		if !strings.HasPrefix(ns, prefix) {
+			invalid = append(invalid, fmt.Sprintf("namespace %q is not part of project set %s", ns, facts.NSPrefix))
+			continue
+		}
+		exists, err := r.namespaceExists(ctx, ns)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			invalid = append(invalid, fmt.Sprintf("namespace %q does not exist", ns))
+			continue
+		}
+		add(ns)
+	}
+
+	if spec.Selector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(spec.Selector)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("invalid namespace selector: %v", err))
+		} else {
+			var list corev1.NamespaceList
+			if err := r.List(ctx, &list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return nil, nil, fmt.Errorf("list namespaces: %w", err)
+			}
+			var matched []string
+			for _, ns := range list.Items {
+				if strings.HasPrefix(ns.Name, prefix) {
+					matched = append(matched, ns.Name)
+				}
+			}
+			sort.Strings(matched)
+			for _, ns := range matched {
+				add(ns)
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, nil, &invalidSpecError{reasons: invalid}
+	}
+	if len(namespaces) == 0 {
+		return nil, nil, &invalidSpecError{reasons: []string{"no namespaces left after exclusions"}}
+	}
+	return namespaces, missing, nil
+}
+
+func (r *SysdigTeamGoReconciler) namespaceExists(ctx context.Context, name string) (bool, error) {
+	var ns corev1.Namespace
+	if err := r.Get(ctx, client.ObjectKey{Name: name}, &ns); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get namespace %s: %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,211 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// channelTypes maps spec.team.notificationChannels types onto Sysdig channel types.
+var channelTypes = map[string]string{
+	"email":   helpers.ChannelTypeEmail,
+	"slack":   helpers.ChannelTypeSlack,
+	"msTeams": helpers.ChannelTypeMSTeams,
+	"webhook": helpers.ChannelTypeWebhook,
+}
+
+// desiredChannels builds the Sysdig channels for spec.team.notificationChannels,
+// reading webhook URLs from their Secrets.
+func (r *SysdigTeamGoReconciler) desiredChannels(
+	ctx context.Context,
+	team *api.SysdigTeam,
+	teamID int64,
+) ([]helpers.NotificationChannel, error) {
+	var invalid []string
+	seen := map[string]bool{}
+	var channels []helpers.NotificationChannel
+	for _, spec := range team.Spec.Team.NotificationChannels {
+		if seen[spec.Name] {
+			invalid = append(invalid, fmt.Sprintf("channel %q is listed twice", spec.Name))
+			continue
+		}
+		seen[spec.Name] = true
+
+		channel := helpers.NotificationChannel{
+			Type:      channelTypes[spec.Type],
+			Name:      spec.Name,
+			TeamID:    teamID,
+			IsEnabled: spec.Enabled == nil || *spec.Enabled,
+		}
+		if spec.Type == "email" {
+			if len(spec.Recipients) == 0 {
+				invalid = append(invalid, fmt.Sprintf("email channel %q has no recipients", spec.Name))
+				continue
+			}
+			channel.Config.EmailRecipients = spec.Recipients
+			channels = append(channels, channel)
+			continue
+		}
+
+		switch {
+		case spec.URL != "" && spec.URLSecretRef != nil:
+			invalid = append(invalid, fmt.Sprintf("channel %q sets both url and urlSecretRef", spec.Name))
+			continue
+		case spec.URLSecretRef != nil:
+			url, err := r.secretValue(ctx, team.Namespace, spec.URLSecretRef)
+			if err != nil {
+				return nil, fmt.Errorf("channel %q: %w", spec.Name, err)
+			}
+			channel.Config.URL = url
+		case spec.URL != "":
+			channel.Config.URL = spec.URL
+		default:
+			invalid = append(invalid, fmt.Sprintf("%s channel %q needs a url or urlSecretRef", spec.Type, spec.Name))
+			continue
+		}
+		if spec.Type == "slack" {
+			channel.Config.Channel = spec.SlackChannel
+		}
+		channels = append(channels, channel)
+	}
+	if len(invalid) > 0 {
+		return nil, &invalidSpecError{reasons: invalid}
+	}
+	return channels, nil
+}
+
+// secretValue reads one key of a Secret in namespace. Only Secrets with the
+// managed-by label are in the cache, so the Secret is read through APIReader.
+func (r *SysdigTeamGoReconciler) secretValue(ctx context.Context, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	var secret corev1.Secret
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, &secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", fmt.Errorf("secret %s not found", ref.Name)
+		}
+		return "", fmt.Errorf("get secret %s: %w", ref.Name, err)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok || len(value) == 0 {
+		return "", fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// syncNotificationChannels creates, updates and deletes the Monitor team's
+// notification channels so they match the spec. Channels edited in the UI
+// are put back. Without a Monitor team every channel is deleted.
+func (r *SysdigTeamGoReconciler) syncNotificationChannels(ctx context.Context, team *api.SysdigTeam) error {
+	logger := log.FromContext(ctx)
+
+	var desired []helpers.NotificationChannel
+	if teamID := team.Status.MonitorTeamID; teamID != 0 {
+		var err error
+		if desired, err = r.desiredChannels(ctx, team, teamID); err != nil {
+			return err
+		}
+	}
+	// Channels are managed in the Monitor team's context. Without a team the
+	// operator's own token deletes what is left.
+	ctx = helpers.WithTeam(ctx, team.Status.MonitorTeamID)
+
+	existing := map[string]int64{}
+	for _, st := range team.Status.NotificationChannels {
+		existing[st.Name] = st.ID
+	}
+	var statuses []api.NotificationChannelStatus
+	defer func() {
+		team.Status.NotificationChannels = statuses
+		for _, name := range sortedKeys(existing) {
+			team.Status.NotificationChannels = append(team.Status.NotificationChannels,
+				api.NotificationChannelStatus{Name: name, ID: existing[name]})
+		}
+	}()
+
+	for _, channel := range desired {
+		id, found := existing[channel.Name]
+		if found {
+			current, err := r.Sysdig.GetNotificationChannel(ctx, id)
+			switch {
+			case helpers.IsNotFound(err):
+				logger.Info("Notification channel was deleted in Sysdig, recreating it", "name", channel.Name, "ID", id)
+				found = false
+			case err != nil:
+				return fmt.Errorf("get notification channel %q: %w", channel.Name, err)
+			case !sameChannel(current, &channel):
+				channel.ID, channel.Version = current.ID, current.Version
+				if err := r.Sysdig.UpdateNotificationChannel(ctx, &channel); err != nil {
+					return fmt.Errorf("update notification channel %q: %w", channel.Name, err)
+				}
+				logger.Info("Updated notification channel", "name", channel.Name, "ID", id)
+			}
+		}
+		if !found {
+			var err error
+			if id, err = r.Sysdig.CreateNotificationChannel(ctx, channel); err != nil {
+				return fmt.Errorf("create notification channel %q: %w", channel.Name, err)
+			}
+			logger.Info("Created notification channel", "name", channel.Name, "ID", id)
+		}
+		delete(existing, channel.Name)
+		statuses = append(statuses, api.NotificationChannelStatus{Name: channel.Name, ID: id})
+	}
+
+	for _, name := range sortedKeys(existing) {
+		if err := r.Sysdig.DeleteNotificationChannel(ctx, existing[name]); err != nil && !helpers.IsNotFound(err) {
+			return fmt.Errorf("delete notification channel %q: %w", name, err)
+		}
+		logger.Info("Deleted notification channel", "name", name, "ID", existing[name])
+		delete(existing, name)
+	}
+	return nil
+}
+
+// sameChannel compares the fields the operator manages.
+func sameChannel(a, b *helpers.NotificationChannel) bool {
+	return a.Type == b.Type && a.Name == b.Name && a.TeamID == b.TeamID &&
+		a.IsEnabled == b.IsEnabled && reflect.DeepEqual(a.Config, b.Config)
+}
+
+// deleteNotificationChannels deletes every channel of a SysdigTeam that is
+// being deleted.
+func (r *SysdigTeamGoReconciler) deleteNotificationChannels(ctx context.Context, team *api.SysdigTeam) error {
+	ctx = helpers.WithTeam(ctx, team.Status.MonitorTeamID)
+	for _, st := range team.Status.NotificationChannels {
+		if err := r.Sysdig.DeleteNotificationChannel(ctx, st.ID); err != nil && !helpers.IsNotFound(err) {
+			return fmt.Errorf("delete notification channel %q: %w", st.Name, err)
+		}
+	}
+	return nil
+}
+
+// teamsForSecret maps a Secret onto the SysdigTeams in its namespace that
+// own it as a token Secret or read a webhook URL from it.
+func (r *SysdigTeamGoReconciler) teamsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	owner := metav1.GetControllerOf(obj)
+	return r.teamsMatching(ctx, func(team *api.SysdigTeam) bool {
+		if team.Namespace != obj.GetNamespace() {
+			return false
+		}
+		if owner != nil && owner.UID == team.UID {
+			return true
+		}
+		return slices.ContainsFunc(team.Spec.Team.NotificationChannels, func(c api.NotificationChannelSpec) bool {
+			return c.URLSecretRef != nil && c.URLSecretRef.Name == obj.GetName()
+		})
+	})
+}
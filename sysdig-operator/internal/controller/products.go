@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"strings"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+)
+
+// productTeam is one Sysdig product team managed for a SysdigTeam.
+type productTeam struct {
+	// product is the Sysdig product, "monitor" or "secure".
+	product string
+	// label is used in condition messages, e.g. "Monitor".
+	label   string
+	name    string
+	spec    *api.ProductSpec
+	clauses []helpers.ScopeClause
+	// teamID points at the status field holding the team ID.
+	teamID *int64
+	// status points at the status field holding the effective settings.
+	status **api.ProductStatus
+}
+
+// enabled reports whether the team should exist. Products are enabled
+// unless spec.team.<product>.enabled is false.
+func (p productTeam) enabled() bool {
+	return p.spec == nil || p.spec.Enabled == nil || *p.spec.Enabled
+}
+
+// description returns the product description, falling back to the shared one.
+func (p productTeam) description(shared string) string {
+	if p.spec != nil && p.spec.Description != "" {
+		return p.spec.Description
+	}
+	return shared
+}
+
+// users merges spec.team.users with the product's own users. A user listed
+// in both gets the product role.
+func (p productTeam) users(shared []api.UserSpec) []helpers.TeamUserRole {
+	var own []api.UserSpec
+	if p.spec != nil {
+		own = p.spec.Users
+	}
+
+	index := map[string]int{}
+	var out []helpers.TeamUserRole
+	for _, u := range append(append([]api.UserSpec{}, shared...), own...) {
+		key := strings.ToLower(strings.TrimSpace(u.Name))
+		if i, ok := index[key]; ok {
+			out[i].Role = u.Role
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, helpers.TeamUserRole{
+			Name: u.Name, // e.g. "billy.li@gov.bc.ca"
+			Role: u.Role, // e.g. "ROLE_TEAM_EDIT"
+		})
+	}
+	return out
+}
+
+// settings clamps the UI settings and permissions of the spec with the
+// product's policy.
+func (p productTeam) settings(policy helpers.TeamPolicy) (helpers.TeamSettings, []string) {
+	var req helpers.SettingsRequest
+	if p.spec != nil {
+		req.Permissions = p.spec.Permissions
+		if p.spec.UI != nil {
+			req.Theme = p.spec.UI.Theme
+			if p.spec.UI.EntryPoint != nil {
+				req.Module = p.spec.UI.EntryPoint.Module
+				req.Selection = p.spec.UI.EntryPoint.Selection
+			}
+		}
+	}
+	return policy.For(p.product).Clamp(req)
+}
+
+// productStatus reports effective settings in the SysdigTeam status.
+func productStatus(settings helpers.TeamSettings, clamped []string) *api.ProductStatus {
+	status := &api.ProductStatus{
+		UI:          api.UISpec{Theme: settings.UI.Theme},
+		Permissions: settings.Permissions,
+		Clamped:     clamped,
+	}
+	if ep := settings.UI.EntryPoint; ep != nil {
+		status.UI.EntryPoint = &api.EntryPointSpec{Module: ep.Module}
+		if ep.Selection != nil {
+			status.UI.EntryPoint.Selection = *ep.Selection
+		}
+	}
+	return status
+}
+
+// teamPolicy returns the platform policy for team settings.
+func (r *SysdigTeamGoReconciler) teamPolicy() helpers.TeamPolicy {
+	if r.TeamPolicy == nil {
+		return helpers.DefaultTeamPolicy()
+	}
+	return *r.TeamPolicy
+}
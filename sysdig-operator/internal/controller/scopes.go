@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+)
+
+// scopeAllowlist returns the labels tenants may narrow their scopes by.
+func (r *SysdigTeamGoReconciler) scopeAllowlist() helpers.ScopeAllowlist {
+	if r.ScopeAllowlist == nil {
+		return helpers.DefaultScopeAllowlist
+	}
+	return r.ScopeAllowlist
+}
+
+// teamScopeClauses converts the clauses in spec.team.scopes and checks them
+// against the allowlist. The cluster label is reserved for ClusterName.
+func (r *SysdigTeamGoReconciler) teamScopeClauses(spec *api.ScopeSpec) (monitor, secure []helpers.ScopeClause, err error) {
+	if spec == nil {
+		spec = &api.ScopeSpec{}
+	}
+
+	var invalid []string
+	convert := func(product string, clauses []api.ScopeClause) []helpers.ScopeClause {
+		out := make([]helpers.ScopeClause, 0, len(clauses))
+		for _, c := range clauses {
+			clause := helpers.ScopeClause{Label: c.Label, Operator: c.Operator, Values: c.Values}
+			if r.ClusterName != "" && c.Label == "kubernetes.cluster.name" {
+				invalid = append(invalid, fmt.Sprintf("%s: label %s is managed by the operator", product, c.Label))
+				continue
+			}
+			if err := r.scopeAllowlist().Validate(clause); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %v", product, err))
+				continue
+			}
+			out = append(out, clause)
+		}
+		return out
+	}
+	monitor = convert("monitor", spec.Monitor)
+	secure = convert("secure", spec.Secure)
+	if len(invalid) > 0 {
+		return nil, nil, &invalidSpecError{reasons: invalid}
+	}
+	return monitor, secure, nil
+}
+
+// agentScope returns the AGENT scope expression of a team, or "".
+func agentScope(team *helpers.TeamDetails) string {
+	for _, scope := range team.Scopes {
+		if scope.Type == "AGENT" {
+			return scope.Expression
+		}
+	}
+	return ""
+}
+
+// ensureTeam updates the description, agent scope, UI settings and managed
+// permissions of an existing team when they no longer match the spec. With a ClusterName the clusters already
+// in the scope are kept, and previous are the namespaces this cluster wrote last, see
+// helpers.MergeScopeExpression.
+func (r *SysdigTeamGoReconciler) ensureTeam(
+	ctx context.Context,
+	teamID int64,
+	description string,
+	previous, namespaces []string,
+	clauses []helpers.ScopeClause,
+	settings helpers.TeamSettings,
+) error {
+	team, err := r.Sysdig.GetTeam(ctx, teamID)
+	if err != nil {
+		return fmt.Errorf("get team %d: %w", teamID, err)
+	}
+
+	desired := helpers.MergeScopeExpression(agentScope(team), r.ClusterName, previous, namespaces, clauses)
+
+	found, changed := false, false
+	if description != "" && team.Description != description {
+		team.Description = description
+		changed = true
+	}
+	for i, scope := range team.Scopes {
+		if scope.Type != "AGENT" {
+			continue
+		}
+		found = true
+		if scope.Expression != desired {
+			team.Scopes[i].Expression = desired
+			changed = true
+		}
+	}
+	if !found {
+		team.Scopes = append(team.Scopes, helpers.Scope{Type: "AGENT", Expression: desired})
+		changed = true
+	}
+	if !sameUISettings(team.UISettings, settings.UI) {
+		team.UISettings = settings.UI
+		changed = true
+	}
+	// Only the permissions the policy knows about are managed; others are left alone.
+	for name, on := range settings.Permissions {
+		if current, ok := team.AdditionalTeamPermissions[name]; !ok || current != on {
+			if team.AdditionalTeamPermissions == nil {
+				team.AdditionalTeamPermissions = map[string]bool{}
+			}
+			team.AdditionalTeamPermissions[name] = on
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if err := r.Sysdig.UpdateTeam(ctx, team); err != nil {
+		return fmt.Errorf("update team %d: %w", teamID, err)
+	}
+	r.Log.Info("Updated Sysdig team", "teamID", teamID, "expression", desired)
+	return nil
+}
+
+// sameUISettings compares the theme and entry point of two UI settings.
+func sameUISettings(a, b helpers.UISettings) bool {
+	if a.Theme != b.Theme {
+		return false
+	}
+	if a.EntryPoint == nil || b.EntryPoint == nil {
+		return a.EntryPoint == b.EntryPoint
+	}
+	selection := func(ep *helpers.EntryPoint) string {
+		if ep.Selection == nil {
+			return ""
+		}
+		return *ep.Selection
+	}
+	return a.EntryPoint.Module == b.EntryPoint.Module && selection(a.EntryPoint) == selection(b.EntryPoint)
+}
+
+// releaseTeam deletes a team when its SysdigTeam goes away. When other
+// clusters still share the team, only this cluster and the namespaces it
+// wrote last (previous) are removed from its scope.
+func (r *SysdigTeamGoReconciler) releaseTeam(ctx context.Context, teamID int64, previous []string) error {
+	if r.ClusterName != "" {
+		team, err := r.Sysdig.GetTeam(ctx, teamID)
+		if err != nil {
+			return fmt.Errorf("get team %d: %w", teamID, err)
+		}
+		if remaining, shared := helpers.RemoveClusterFromScope(agentScope(team), r.ClusterName, previous); shared {
+			for i := range team.Scopes {
+				if team.Scopes[i].Type == "AGENT" {
+					team.Scopes[i].Expression = remaining
+				}
+			}
+			if err := r.Sysdig.UpdateTeam(ctx, team); err != nil {
+				return fmt.Errorf("remove cluster %s from team %d: %w", r.ClusterName, teamID, err)
+			}
+			r.Log.Info("Team is shared with other clusters, removed this cluster from its scope",
+				"teamID", teamID, "cluster", r.ClusterName)
+			return nil
+		}
+	}
+	return r.Sysdig.DeleteTeam(ctx, teamID)
+}
@@ -0,0 +1,338 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// defaultTokenLifetime is used when a service account sets no lifetime.
+	defaultTokenLifetime = 90 * 24 * time.Hour
+	// serviceAccountTokenKey is the Secret key holding the API token.
+	serviceAccountTokenKey = "token"
+	// managedByLabel marks the Secrets the operator reads and writes.
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "sysdig-operator"
+)
+
+// ManagedSecretSelector selects the Secrets the operator works with: the
+// token Secrets it writes and the webhook URL Secrets tenants label for it.
+// The manager's Secret cache is limited to it.
+func ManagedSecretSelector() labels.Selector {
+	return labels.SelectorFromSet(labels.Set{managedByLabel: managedByValue})
+}
+
+// serviceAccountProduct returns the product of a spec entry, "monitor" by default.
+func serviceAccountProduct(sa api.ServiceAccountSpec) string {
+	if sa.Product == "" {
+		return "monitor"
+	}
+	return sa.Product
+}
+
+// serviceAccountSecretName returns the Secret the token of sa is written to.
+func serviceAccountSecretName(sa api.ServiceAccountSpec) string {
+	if sa.SecretName != "" {
+		return sa.SecretName
+	}
+	return fmt.Sprintf("sysdig-%s-%s", serviceAccountProduct(sa), sa.Name)
+}
+
+// tokenTimes returns when a token issued at now expires and when it is rotated.
+func tokenTimes(sa api.ServiceAccountSpec, now time.Time) (expiresAt, rotateAt time.Time) {
+	lifetime := defaultTokenLifetime
+	if sa.Lifetime != nil && sa.Lifetime.Duration > 0 {
+		lifetime = sa.Lifetime.Duration
+	}
+	rotateAfter := lifetime * 2 / 3
+	if sa.RotateAfter != nil && sa.RotateAfter.Duration > 0 && sa.RotateAfter.Duration < lifetime {
+		rotateAfter = sa.RotateAfter.Duration
+	}
+	return now.Add(lifetime), now.Add(rotateAfter)
+}
+
+// syncServiceAccounts issues a token for every spec.team.serviceAccounts
+// entry, rotates tokens that are due, and deletes the accounts and Secrets
+// of removed entries. It returns how long until the next rotation, or 0.
+// Status is kept up to date even when it fails half way, so no account the
+// operator created is forgotten.
+func (r *SysdigTeamGoReconciler) syncServiceAccounts(ctx context.Context, team *api.SysdigTeam) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+	now := time.Now()
+
+	existing := map[string]api.ServiceAccountStatus{}
+	for _, st := range team.Status.ServiceAccounts {
+		existing[st.Product+"/"+st.Name] = st
+	}
+	var statuses []api.ServiceAccountStatus
+	record := func() {
+		team.Status.ServiceAccounts = statuses
+		for _, key := range sortedKeys(existing) {
+			team.Status.ServiceAccounts = append(team.Status.ServiceAccounts, existing[key])
+		}
+	}
+	defer record()
+
+	var next time.Time
+	for _, sa := range team.Spec.Team.ServiceAccounts {
+		product := serviceAccountProduct(sa)
+		teamID := team.Status.MonitorTeamID
+		if product == "secure" {
+			teamID = team.Status.SecureTeamID
+		}
+		if teamID == 0 {
+			// The product is disabled; any account left over is cleaned up below.
+			logger.Info("Product team is disabled, not issuing its service account", "product", product, "name", sa.Name)
+			continue
+		}
+
+		key := product + "/" + sa.Name
+		current, found := existing[key]
+		secretName := serviceAccountSecretName(sa)
+		if found {
+			valid, err := r.serviceAccountValid(ctx, team, current, sa, teamID, secretName, now)
+			if err != nil {
+				return 0, err
+			}
+			if valid {
+				delete(existing, key)
+				statuses = append(statuses, current)
+				next = earliest(next, current.RotateAt.Time)
+				continue
+			}
+		}
+
+		issued, err := r.issueServiceAccount(ctx, team, sa, teamID, now)
+		if err != nil {
+			return 0, err
+		}
+		statuses = append(statuses, issued)
+		next = earliest(next, issued.RotateAt.Time)
+
+		if found {
+			delete(existing, key)
+			// The old token expires on its own, so failing to revoke it early is not fatal.
+			if err := r.Sysdig.DeleteServiceAccount(ctx, current.TeamID, current.ID); err != nil && !helpers.IsNotFound(err) {
+				logger.Error(err, "Failed to delete rotated service account", "name", sa.Name, "ID", current.ID)
+			}
+			if current.SecretName != secretName {
+				if err := r.deleteServiceAccountSecret(ctx, team, current.SecretName); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(existing) {
+		st := existing[key]
+		if err := r.Sysdig.DeleteServiceAccount(ctx, st.TeamID, st.ID); err != nil && !helpers.IsNotFound(err) {
+			return 0, fmt.Errorf("delete service account %s: %w", key, err)
+		}
+		if err := r.deleteServiceAccountSecret(ctx, team, st.SecretName); err != nil {
+			return 0, err
+		}
+		delete(existing, key)
+		logger.Info("Deleted team service account", "product", st.Product, "name", st.Name, "ID", st.ID)
+	}
+
+	if next.IsZero() {
+		return 0, nil
+	}
+	return max(time.Until(next), time.Second), nil
+}
+
+// serviceAccountValid reports whether a recorded account can be kept: it
+// still matches the spec, is not due for rotation, and its Secret holds a token.
+func (r *SysdigTeamGoReconciler) serviceAccountValid(
+	ctx context.Context,
+	team *api.SysdigTeam,
+	current api.ServiceAccountStatus,
+	sa api.ServiceAccountSpec,
+	teamID int64,
+	secretName string,
+	now time.Time,
+) (bool, error) {
+	if current.TeamID != teamID || current.Role != sa.Role || current.SecretName != secretName ||
+		!now.Before(current.RotateAt.Time) {
+		return false, nil
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: team.Namespace, Name: secretName}, &secret); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("get secret %s: %w", secretName, err)
+	}
+	return len(secret.Data[serviceAccountTokenKey]) > 0, nil
+}
+
+// issueServiceAccount creates a new Sysdig service account and writes its
+// token to the Secret. Each token gets its own account, named after the
+// spec entry and the time it was issued, so the old one can be revoked
+// once the Secret holds the new token.
+func (r *SysdigTeamGoReconciler) issueServiceAccount(
+	ctx context.Context,
+	team *api.SysdigTeam,
+	sa api.ServiceAccountSpec,
+	teamID int64,
+	now time.Time,
+) (api.ServiceAccountStatus, error) {
+	product := serviceAccountProduct(sa)
+	expiresAt, rotateAt := tokenTimes(sa, now)
+	name := fmt.Sprintf("%s-%s", sa.Name, now.UTC().Format("20060102150405"))
+
+	if err := r.checkTokenSecret(ctx, team, serviceAccountSecretName(sa)); err != nil {
+		return api.ServiceAccountStatus{}, err
+	}
+
+	account, err := r.Sysdig.CreateServiceAccount(ctx, teamID, name, sa.Role, expiresAt)
+	if err != nil {
+		return api.ServiceAccountStatus{}, fmt.Errorf("create service account %s/%s: %w", product, sa.Name, err)
+	}
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: team.Namespace, Name: serviceAccountSecretName(sa)}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[managedByLabel] = managedByValue
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations["ops.gov.bc.ca/sysdig-token-expires-at"] = expiresAt.UTC().Format(time.RFC3339)
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			serviceAccountTokenKey: []byte(account.APIKey),
+			"product":              []byte(product),
+			"team-id":              []byte(strconv.FormatInt(teamID, 10)),
+		}
+		return controllerutil.SetControllerReference(team, secret, r.Scheme)
+	})
+	if err != nil {
+		// Without the Secret nobody can use the token, so revoke it right away.
+		if delErr := r.Sysdig.DeleteServiceAccount(ctx, teamID, account.ID); delErr != nil {
+			log.FromContext(ctx).Error(delErr, "Failed to delete service account after the Secret write failed", "ID", account.ID)
+		}
+		return api.ServiceAccountStatus{}, fmt.Errorf("write secret %s: %w", secret.Name, err)
+	}
+
+	log.FromContext(ctx).Info("Issued team service account token",
+		"product", product, "name", sa.Name, "ID", account.ID, "secret", secret.Name, "rotateAt", rotateAt)
+	return api.ServiceAccountStatus{
+		Name:       sa.Name,
+		Product:    product,
+		Role:       sa.Role,
+		TeamID:     teamID,
+		ID:         account.ID,
+		SecretName: secret.Name,
+		ExpiresAt:  metav1.NewTime(expiresAt),
+		RotateAt:   metav1.NewTime(rotateAt),
+	}, nil
+}
+
+// checkTokenSecret makes sure a token Secret can be written: it does not
+// exist yet, or it is labelled for the operator and controlled by team.
+// Secrets outside the cache are read through APIReader, so a tenant's own
+// Secret of the same name is never overwritten.
+func (r *SysdigTeamGoReconciler) checkTokenSecret(ctx context.Context, team *api.SysdigTeam, name string) error {
+	var reader client.Reader = r.Client
+	if r.APIReader != nil {
+		reader = r.APIReader
+	}
+	var secret corev1.Secret
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: team.Namespace, Name: name}, &secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get secret %s: %w", name, err)
+	}
+	if secret.Labels[managedByLabel] != managedByValue || !metav1.IsControlledBy(&secret, team) {
+		return fmt.Errorf("secret %s exists and is not managed by this SysdigTeam, refusing to overwrite it", name)
+	}
+	return nil
+}
+
+// deleteServiceAccountSecret deletes a token Secret, unless it is gone or
+// not owned by team.
+func (r *SysdigTeamGoReconciler) deleteServiceAccountSecret(ctx context.Context, team *api.SysdigTeam, name string) error {
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: team.Namespace, Name: name}, &secret); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("get secret %s: %w", name, err)
+	}
+	if !metav1.IsControlledBy(&secret, team) {
+		return nil
+	}
+	if err := r.Delete(ctx, &secret); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("delete secret %s: %w", name, err)
+	}
+	return nil
+}
+
+// deleteServiceAccounts revokes every service account of a SysdigTeam that
+// is being deleted. Their Secrets are garbage collected with it.
+func (r *SysdigTeamGoReconciler) deleteServiceAccounts(ctx context.Context, team *api.SysdigTeam) error {
+	for _, st := range team.Status.ServiceAccounts {
+		if err := r.Sysdig.DeleteServiceAccount(ctx, st.TeamID, st.ID); err != nil && !helpers.IsNotFound(err) {
+			return fmt.Errorf("delete service account %s/%s: %w", st.Product, st.Name, err)
+		}
+	}
+	return nil
+}
+
+// deleteProductServiceAccounts revokes the service accounts of a product
+// whose team is about to be deleted, and deletes their Secrets. Status keeps
+// the accounts that could not be deleted yet.
+func (r *SysdigTeamGoReconciler) deleteProductServiceAccounts(ctx context.Context, team *api.SysdigTeam, product string) error {
+	var remaining []api.ServiceAccountStatus
+	for i, st := range team.Status.ServiceAccounts {
+		if st.Product != product {
+			remaining = append(remaining, st)
+			continue
+		}
+		err := r.Sysdig.DeleteServiceAccount(ctx, st.TeamID, st.ID)
+		if err != nil && !helpers.IsNotFound(err) {
+			err = fmt.Errorf("delete service account %s/%s: %w", st.Product, st.Name, err)
+		} else {
+			err = r.deleteServiceAccountSecret(ctx, team, st.SecretName)
+		}
+		if err != nil {
+			team.Status.ServiceAccounts = append(remaining, team.Status.ServiceAccounts[i:]...)
+			return err
+		}
+		log.FromContext(ctx).Info("Deleted team service account", "product", st.Product, "name", st.Name, "ID", st.ID)
+	}
+	team.Status.ServiceAccounts = remaining
+	return nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
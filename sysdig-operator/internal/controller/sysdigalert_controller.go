@@ -0,0 +1,257 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// alertSeverities maps spec.severity onto the Sysdig severity levels.
+var alertSeverities = map[string]int{
+	"high":   0,
+	"medium": 2,
+	"low":    4,
+	"info":   6,
+}
+
+// metricNamePattern matches spec.metric.metric, which is put into the alert
+// condition as is.
+var metricNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// SysdigAlertReconciler reconciles a SysdigAlert object
+type SysdigAlertReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Sysdig is the Sysdig API client shared by all reconciles.
+	// A nil client means the operator was started without credentials.
+	Sysdig helpers.SysdigAPI
+
+	// ClusterName is the kubernetes.cluster.name of this cluster. When set,
+	// metric alerts are filtered to it as well as to the team namespaces.
+	ClusterName string
+}
+
+// teamResource returns the helpers shared with the SysdigDashboard reconciler.
+func (r *SysdigAlertReconciler) teamResource() *teamResource {
+	return &teamResource{Client: r.Client, sysdig: r.Sysdig, kind: "alert", finalizer: sysdigAlertFinalizer}
+}
+
+// channelIDs resolves notification channel names against the status of team.
+func channelIDs(team *api.SysdigTeam, names []string) ([]int64, []string) {
+	var ids []int64
+	var missing []string
+	for _, name := range names {
+		i := slices.IndexFunc(team.Status.NotificationChannels, func(c api.NotificationChannelStatus) bool {
+			return c.Name == name
+		})
+		if i < 0 {
+			missing = append(missing, name)
+			continue
+		}
+		ids = append(ids, team.Status.NotificationChannels[i].ID)
+	}
+	return ids, missing
+}
+
+// desiredAlert builds the Sysdig alert for a SysdigAlert in team. Metric
+// alerts are filtered to the cluster and namespaces of the team's agent
+// scope; PromQL queries must limit every selector to the team namespaces.
+func desiredAlert(alert *api.SysdigAlert, team *api.SysdigTeam, cluster string) (helpers.Alert, error) {
+	spec := alert.Spec
+	var invalid []string
+
+	out := helpers.Alert{
+		TeamID:      team.Status.MonitorTeamID,
+		Name:        spec.Name,
+		Description: spec.Description,
+		Enabled:     spec.Enabled == nil || *spec.Enabled,
+		Timespan:    spec.Duration.Microseconds(),
+	}
+	if out.Name == "" {
+		out.Name = alert.Name
+	}
+	severity := spec.Severity
+	if severity == "" {
+		severity = "medium"
+	}
+	out.Severity = alertSeverities[severity]
+	if out.Timespan <= 0 {
+		invalid = append(invalid, "duration must be positive")
+	}
+
+	switch {
+	case spec.PromQL != nil && spec.Metric != nil:
+		invalid = append(invalid, "set only one of promql and metric")
+	case spec.PromQL != nil:
+		out.Type = helpers.AlertTypePrometheus
+		out.Condition = strings.TrimSpace(spec.PromQL.Query)
+		if out.Condition == "" {
+			invalid = append(invalid, "promql.query is empty")
+		} else if err := helpers.CheckPromQLScope(out.Condition, team.Status.Namespaces); err != nil {
+			invalid = append(invalid, "promql.query: "+err.Error())
+		}
+	case spec.Metric != nil:
+		aggregation := spec.Metric.Aggregation
+		if aggregation == "" {
+			aggregation = "avg"
+		}
+		if !metricNamePattern.MatchString(spec.Metric.Metric) {
+			invalid = append(invalid, fmt.Sprintf("metric.metric %q may only contain letters, digits, _ and .", spec.Metric.Metric))
+		}
+		out.Type = helpers.AlertTypeMetric
+		out.Condition = fmt.Sprintf("%s(%s(%s)) %s %s",
+			aggregation, aggregation, spec.Metric.Metric, spec.Metric.Operator, spec.Metric.Threshold)
+		out.Filter = helpers.MergeScopeExpression("", cluster, nil, team.Status.Namespaces, nil)
+	default:
+		invalid = append(invalid, "one of promql and metric is required")
+	}
+
+	ids, missing := channelIDs(team, spec.NotificationChannels)
+	for _, name := range missing {
+		invalid = append(invalid, fmt.Sprintf("notification channel %q is not managed by SysdigTeam %s", name, team.Name))
+	}
+	out.NotificationChannelIDs = ids
+
+	if len(invalid) > 0 {
+		return helpers.Alert{}, &invalidSpecError{reasons: invalid}
+	}
+	return out, nil
+}
+
+// sameAlert compares the fields the operator manages.
+func sameAlert(a, b *helpers.Alert) bool {
+	return a.Type == b.Type && a.Name == b.Name && a.Description == b.Description &&
+		a.Severity == b.Severity && a.Enabled == b.Enabled && a.Timespan == b.Timespan &&
+		a.Condition == b.Condition && a.Filter == b.Filter &&
+		slices.Equal(a.NotificationChannelIDs, b.NotificationChannelIDs)
+}
+
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-alerts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-alerts/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-alerts/finalizers,verbs=update
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-teams,verbs=get;list;watch
+
+// Reconcile creates or updates the Sysdig alert of a SysdigAlert in the
+// Monitor team of its namespace, and deletes it with the SysdigAlert.
+func (r *SysdigAlertReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var alert api.SysdigAlert
+	if err := r.Get(ctx, req.NamespacedName, &alert); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	t := r.teamResource()
+	obj := teamObject{Object: &alert, conditions: &alert.Status.Conditions}
+	if !alert.DeletionTimestamp.IsZero() {
+		return t.finalize(ctx, obj, alert.Status.ID, func() error {
+			return r.Sysdig.DeleteAlert(helpers.WithTeam(ctx, alert.Status.TeamID), alert.Status.ID)
+		})
+	}
+
+	team, result, err := t.prepare(ctx, obj)
+	if team == nil {
+		return result, err
+	}
+
+	desired, err := desiredAlert(&alert, team, r.ClusterName)
+	if invalid, ok := err.(*invalidSpecError); ok {
+		// Don't requeue, the spec has to change.
+		return ctrl.Result{}, t.setSynced(ctx, obj, metav1.ConditionFalse, "InvalidSpec", invalid.Error())
+	}
+
+	if alert.Status.ID != 0 && alert.Status.TeamID != desired.TeamID {
+		// The Monitor team was recreated, so the alert has to be too.
+		previous := helpers.WithTeam(ctx, alert.Status.TeamID)
+		if err := r.Sysdig.DeleteAlert(previous, alert.Status.ID); err != nil && !helpers.IsNotFound(err) {
+			return t.fail(ctx, obj, "Failed to delete alert of the previous team", err)
+		}
+		alert.Status.ID = 0
+	}
+
+	// Alerts are read and written in the Monitor team's context.
+	teamCtx := helpers.WithTeam(ctx, desired.TeamID)
+	if alert.Status.ID != 0 {
+		current, err := r.Sysdig.GetAlert(teamCtx, alert.Status.ID)
+		switch {
+		case helpers.IsNotFound(err):
+			logger.Info("Sysdig alert was deleted, recreating it", "ID", alert.Status.ID)
+			alert.Status.ID = 0
+		case err != nil:
+			return t.fail(ctx, obj, "Failed to get alert", err)
+		case !sameAlert(current, &desired):
+			desired.ID, desired.Version = current.ID, current.Version
+			if _, err := r.Sysdig.UpdateAlert(teamCtx, &desired); err != nil {
+				return t.fail(ctx, obj, "Failed to update alert", err)
+			}
+			logger.Info("Updated Sysdig alert", "ID", current.ID)
+		}
+	}
+	if alert.Status.ID == 0 {
+		created, err := r.Sysdig.CreateAlert(teamCtx, desired)
+		if err != nil {
+			return t.fail(ctx, obj, "Failed to create alert", err)
+		}
+		alert.Status.ID = created.ID
+		logger.Info("Created Sysdig alert", "ID", created.ID, "teamID", desired.TeamID)
+	}
+
+	alert.Status.TeamID = desired.TeamID
+	alert.Status.ObservedGeneration = alert.Generation
+	return ctrl.Result{}, t.setSynced(ctx, obj, metav1.ConditionTrue, "Synced", "Alert is in sync with Sysdig")
+}
+
+// alertsForTeam maps a SysdigTeam onto the SysdigAlerts of its namespace.
+func (r *SysdigAlertReconciler) alertsForTeam(ctx context.Context, obj client.Object) []reconcile.Request {
+	var alerts api.SysdigAlertList
+	if err := r.List(ctx, &alerts, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list SysdigAlerts for a SysdigTeam change")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(alerts.Items))
+	for i := range alerts.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&alerts.Items[i])})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SysdigAlertReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.SysdigAlert{}).
+		Watches(&api.SysdigTeam{}, handler.EnqueueRequestsFromMapFunc(r.alertsForTeam)).
+		Complete(r)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	opsv1alpha1 "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+)
+
+var _ = Describe("SysdigAlert Controller", func() {
+	const namespace = "vwx234-tools"
+
+	ctx := context.Background()
+	alertName := types.NamespacedName{Name: "restarts", Namespace: namespace}
+	teamName := types.NamespacedName{Name: "vwx234-team", Namespace: namespace}
+
+	BeforeEach(func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+		err := k8sClient.Create(ctx, ns)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		team := &opsv1alpha1.SysdigTeam{ObjectMeta: metav1.ObjectMeta{Name: teamName.Name, Namespace: namespace}}
+		Expect(k8sClient.Create(ctx, team)).To(Succeed())
+		team.Status.MonitorTeamID = 42
+		team.Status.Namespaces = []string{"vwx234-tools", "vwx234-prod"}
+		team.Status.NotificationChannels = []opsv1alpha1.NotificationChannelStatus{{Name: "on-call", ID: 7}}
+		Expect(k8sClient.Status().Update(ctx, team)).To(Succeed())
+
+		alert := &opsv1alpha1.SysdigAlert{
+			ObjectMeta: metav1.ObjectMeta{Name: alertName.Name, Namespace: namespace},
+			Spec: opsv1alpha1.SysdigAlertSpec{
+				Severity: "high",
+				Duration: metav1.Duration{Duration: 10 * time.Minute},
+				Metric: &opsv1alpha1.MetricAlertSpec{
+					Metric: "cpu.used.percent", Aggregation: "avg", Operator: ">", Threshold: "80",
+				},
+				NotificationChannels: []string{"on-call"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, alert)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		team := &opsv1alpha1.SysdigTeam{}
+		Expect(k8sClient.Get(ctx, teamName, team)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, team)).To(Succeed())
+		alert := &opsv1alpha1.SysdigAlert{}
+		if err := k8sClient.Get(ctx, alertName, alert); err == nil {
+			alert.Finalizers = nil
+			Expect(k8sClient.Update(ctx, alert)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, alert)).To(Succeed())
+		}
+	})
+
+	It("creates the alert in the Monitor team, keeps it in sync and deletes it", func() {
+		fake := newFakeSysdig()
+		r := &SysdigAlertReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Sysdig: fake, ClusterName: "silver"}
+		reconcileOnce := func() {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: alertName})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		alert := &opsv1alpha1.SysdigAlert{}
+		Expect(k8sClient.Get(ctx, alertName, alert)).To(Succeed())
+		alert.Finalizers = []string{sysdigTeamFinalizer}
+		Expect(k8sClient.Update(ctx, alert)).To(Succeed())
+		reconcileOnce()
+
+		Expect(k8sClient.Get(ctx, alertName, alert)).To(Succeed())
+		Expect(alert.Finalizers).To(ConsistOf(sysdigAlertFinalizer))
+		Expect(alert.Status.Conditions).To(ConsistOf(HaveField("Status", metav1.ConditionTrue)))
+		created := fake.alerts[alert.Status.ID]
+		Expect(created.TeamID).To(Equal(int64(42)))
+		Expect(created.Severity).To(Equal(0))
+		Expect(created.Timespan).To(Equal((10 * time.Minute).Microseconds()))
+		Expect(created.Condition).To(Equal("avg(avg(cpu.used.percent)) > 80"))
+		Expect(created.Filter).To(Equal(`kubernetes.cluster.name in ("silver")` +
+			` and kubernetes.namespace.name in ("vwx234-tools","vwx234-prod")`))
+		Expect(created.NotificationChannelIDs).To(Equal([]int64{7}))
+
+		By("updating the alert when the spec changes")
+		alert.Spec.Metric.Threshold = "90"
+		Expect(k8sClient.Update(ctx, alert)).To(Succeed())
+		reconcileOnce()
+		Expect(fake.alerts[alert.Status.ID].Condition).To(Equal("avg(avg(cpu.used.percent)) > 90"))
+		Expect(fake.alerts).To(HaveLen(1))
+
+		By("reporting channels the team does not manage")
+		Expect(k8sClient.Get(ctx, alertName, alert)).To(Succeed())
+		alert.Spec.NotificationChannels = []string{"nope"}
+		Expect(k8sClient.Update(ctx, alert)).To(Succeed())
+		reconcileOnce()
+		Expect(k8sClient.Get(ctx, alertName, alert)).To(Succeed())
+		Expect(alert.Status.Conditions).To(ConsistOf(HaveField("Reason", "InvalidSpec")))
+
+		By("rejecting PromQL selectors that are not limited to the team namespaces")
+		alert.Spec.NotificationChannels = []string{"on-call"}
+		alert.Spec.Metric = nil
+		alert.Spec.PromQL = &opsv1alpha1.PromQLAlertSpec{Query: "sum(kube_pod_container_status_restarts_total) > 5"}
+		Expect(k8sClient.Update(ctx, alert)).To(Succeed())
+		reconcileOnce()
+		Expect(k8sClient.Get(ctx, alertName, alert)).To(Succeed())
+		Expect(alert.Status.Conditions).To(ConsistOf(And(
+			HaveField("Reason", "InvalidSpec"),
+			HaveField("Message", ContainSubstring("no kube_namespace_name matcher")),
+		)))
+
+		By("keeping the finalizer while there is no Sysdig client")
+		Expect(k8sClient.Delete(ctx, alert)).To(Succeed())
+		withoutClient := &SysdigAlertReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		_, err := withoutClient.Reconcile(ctx, reconcile.Request{NamespacedName: alertName})
+		Expect(err).To(HaveOccurred())
+		Expect(k8sClient.Get(ctx, alertName, alert)).To(Succeed())
+		Expect(alert.Finalizers).NotTo(BeEmpty())
+
+		By("deleting the Sysdig alert with the SysdigAlert")
+		reconcileOnce()
+		Expect(fake.alerts).To(BeEmpty())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, alertName, alert))).To(BeTrue())
+	})
+})
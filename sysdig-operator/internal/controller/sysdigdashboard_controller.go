@@ -0,0 +1,266 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// dashboardRoles maps spec.sharing.teamRole onto Sysdig dashboard roles.
+var dashboardRoles = map[string]string{
+	"read": "ROLE_RESOURCE_READ",
+	"edit": "ROLE_RESOURCE_EDIT",
+}
+
+// SysdigDashboardReconciler reconciles a SysdigDashboard object
+type SysdigDashboardReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Sysdig is the Sysdig API client shared by all reconciles.
+	// A nil client means the operator was started without credentials.
+	Sysdig helpers.SysdigAPI
+}
+
+// teamResource returns the helpers shared with the SysdigAlert reconciler.
+func (r *SysdigDashboardReconciler) teamResource() *teamResource {
+	return &teamResource{Client: r.Client, sysdig: r.Sysdig, kind: "dashboard", finalizer: sysdigDashboardFinalizer}
+}
+
+// dashboardSource returns the dashboard JSON of a SysdigDashboard.
+func (r *SysdigDashboardReconciler) dashboardSource(ctx context.Context, dashboard *api.SysdigDashboard) (string, error) {
+	spec := dashboard.Spec
+	switch {
+	case spec.JSON != "" && spec.ConfigMapRef != nil:
+		return "", &invalidSpecError{reasons: []string{"set only one of json and configMapRef"}}
+	case spec.JSON != "":
+		return spec.JSON, nil
+	case spec.ConfigMapRef == nil:
+		return "", &invalidSpecError{reasons: []string{"one of json and configMapRef is required"}}
+	}
+
+	ref := spec.ConfigMapRef
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, client.ObjectKey{Namespace: dashboard.Namespace, Name: ref.Name}, &cm); err != nil {
+		if errors.IsNotFound(err) {
+			return "", &invalidSpecError{reasons: []string{fmt.Sprintf("ConfigMap %s not found", ref.Name)}}
+		}
+		return "", fmt.Errorf("get ConfigMap %s: %w", ref.Name, err)
+	}
+	data, ok := cm.Data[ref.Key]
+	if !ok {
+		return "", &invalidSpecError{reasons: []string{fmt.Sprintf("ConfigMap %s has no key %s", ref.Name, ref.Key)}}
+	}
+	return data, nil
+}
+
+// desiredDashboard builds the Sysdig dashboard for a SysdigDashboard in
+// team, from its JSON.
+func desiredDashboard(dashboard *api.SysdigDashboard, team *api.SysdigTeam, source string) (*helpers.Dashboard, error) {
+	out, err := helpers.ParseDashboard([]byte(source))
+	if err != nil {
+		return nil, &invalidSpecError{reasons: []string{err.Error()}}
+	}
+	out.TeamID = team.Status.MonitorTeamID
+	if dashboard.Spec.Name != "" {
+		out.Name = dashboard.Spec.Name
+	}
+	if out.Name == "" {
+		out.Name = dashboard.Name
+	}
+
+	role, public := "read", false
+	if sharing := dashboard.Spec.Sharing; sharing != nil {
+		if sharing.TeamRole != "" {
+			role = sharing.TeamRole
+		}
+		public = sharing.Public
+	}
+	out.Public = public
+	out.SharingSettings = nil
+	if sysdigRole, ok := dashboardRoles[role]; ok {
+		out.SharingSettings = []helpers.DashboardShare{{
+			Role:   sysdigRole,
+			Member: helpers.DashboardShareMember{Type: "TEAM", ID: out.TeamID},
+		}}
+	}
+	return out, nil
+}
+
+// dashboardHash returns a hash of everything the operator sends for a dashboard.
+func dashboardHash(d *helpers.Dashboard) (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-dashboards,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-dashboards/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-dashboards/finalizers,verbs=update
+// +kubebuilder:rbac:groups=ops.gov.bc.ca,resources=sysdig-teams,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+
+// Reconcile creates or updates the Sysdig dashboard of a SysdigDashboard in
+// the Monitor team of its namespace, and deletes it with the SysdigDashboard.
+// The dashboard is updated when the spec or JSON changes, and when someone
+// edits it in Sysdig.
+func (r *SysdigDashboardReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	var dashboard api.SysdigDashboard
+	if err := r.Get(ctx, req.NamespacedName, &dashboard); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	t := r.teamResource()
+	obj := teamObject{Object: &dashboard, conditions: &dashboard.Status.Conditions}
+	if !dashboard.DeletionTimestamp.IsZero() {
+		return t.finalize(ctx, obj, dashboard.Status.ID, func() error {
+			return r.Sysdig.DeleteDashboard(helpers.WithTeam(ctx, dashboard.Status.TeamID), dashboard.Status.ID)
+		})
+	}
+
+	team, result, err := t.prepare(ctx, obj)
+	if team == nil {
+		return result, err
+	}
+
+	source, err := r.dashboardSource(ctx, &dashboard)
+	var desired *helpers.Dashboard
+	if err == nil {
+		desired, err = desiredDashboard(&dashboard, team, source)
+	}
+	if invalid, ok := err.(*invalidSpecError); ok {
+		// Don't requeue, the spec or ConfigMap has to change.
+		return ctrl.Result{}, t.setSynced(ctx, obj, metav1.ConditionFalse, "InvalidSpec", invalid.Error())
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	hash, err := dashboardHash(desired)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := &dashboard.Status
+	if status.ID != 0 && status.TeamID != desired.TeamID {
+		// The Monitor team was recreated, so the dashboard has to be too.
+		previous := helpers.WithTeam(ctx, status.TeamID)
+		if err := r.Sysdig.DeleteDashboard(previous, status.ID); err != nil && !helpers.IsNotFound(err) {
+			return t.fail(ctx, obj, "Failed to delete dashboard of the previous team", err)
+		}
+		status.ID = 0
+	}
+
+	// Dashboards are read and written in the Monitor team's context.
+	teamCtx := helpers.WithTeam(ctx, desired.TeamID)
+	if status.ID != 0 {
+		current, err := r.Sysdig.GetDashboard(teamCtx, status.ID)
+		switch {
+		case helpers.IsNotFound(err):
+			logger.Info("Sysdig dashboard was deleted, recreating it", "ID", status.ID)
+			status.ID = 0
+		case err != nil:
+			return t.fail(ctx, obj, "Failed to get dashboard", err)
+		case current.Version != status.Version || hash != status.ContentHash:
+			desired.ID, desired.Version = current.ID, current.Version
+			updated, err := r.Sysdig.UpdateDashboard(teamCtx, desired)
+			if err != nil {
+				return t.fail(ctx, obj, "Failed to update dashboard", err)
+			}
+			status.Version = updated.Version
+			logger.Info("Updated Sysdig dashboard", "ID", current.ID, "version", updated.Version)
+		}
+	}
+	if status.ID == 0 {
+		created, err := r.Sysdig.CreateTeamDashboard(teamCtx, desired)
+		if err != nil {
+			return t.fail(ctx, obj, "Failed to create dashboard", err)
+		}
+		status.ID, status.Version = created.ID, created.Version
+		logger.Info("Created Sysdig dashboard", "ID", created.ID, "teamID", desired.TeamID)
+	}
+
+	status.TeamID = desired.TeamID
+	status.ContentHash = hash
+	status.ObservedGeneration = dashboard.Generation
+	return ctrl.Result{}, t.setSynced(ctx, obj, metav1.ConditionTrue, "Synced", "Dashboard is in sync with Sysdig")
+}
+
+// dashboardsMatching returns requests for the SysdigDashboards in namespace
+// that match.
+func (r *SysdigDashboardReconciler) dashboardsMatching(
+	ctx context.Context,
+	namespace string,
+	match func(*api.SysdigDashboard) bool,
+) []reconcile.Request {
+	var dashboards api.SysdigDashboardList
+	if err := r.List(ctx, &dashboards, client.InNamespace(namespace)); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list SysdigDashboards")
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range dashboards.Items {
+		if match(&dashboards.Items[i]) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dashboards.Items[i])})
+		}
+	}
+	return requests
+}
+
+// dashboardsForTeam maps a SysdigTeam onto the SysdigDashboards of its namespace.
+func (r *SysdigDashboardReconciler) dashboardsForTeam(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.dashboardsMatching(ctx, obj.GetNamespace(), func(*api.SysdigDashboard) bool { return true })
+}
+
+// dashboardsForConfigMap maps a ConfigMap onto the SysdigDashboards reading from it.
+func (r *SysdigDashboardReconciler) dashboardsForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.dashboardsMatching(ctx, obj.GetNamespace(), func(d *api.SysdigDashboard) bool {
+		return d.Spec.ConfigMapRef != nil && d.Spec.ConfigMapRef.Name == obj.GetName()
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SysdigDashboardReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&api.SysdigDashboard{}).
+		Watches(&api.SysdigTeam{}, handler.EnqueueRequestsFromMapFunc(r.dashboardsForTeam)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.dashboardsForConfigMap)).
+		Complete(r)
+}
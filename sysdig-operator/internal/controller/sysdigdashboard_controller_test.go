@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	opsv1alpha1 "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+)
+
+var _ = Describe("SysdigDashboard Controller", func() {
+	const namespace = "yza567-tools"
+
+	ctx := context.Background()
+	dashboardName := types.NamespacedName{Name: "latency", Namespace: namespace}
+	teamName := types.NamespacedName{Name: "yza567-team", Namespace: namespace}
+	configMapName := types.NamespacedName{Name: "dashboards", Namespace: namespace}
+
+	BeforeEach(func() {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+		err := k8sClient.Create(ctx, ns)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		team := &opsv1alpha1.SysdigTeam{ObjectMeta: metav1.ObjectMeta{Name: teamName.Name, Namespace: namespace}}
+		Expect(k8sClient.Create(ctx, team)).To(Succeed())
+		team.Status.MonitorTeamID = 42
+		Expect(k8sClient.Status().Update(ctx, team)).To(Succeed())
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configMapName.Name, Namespace: namespace},
+			Data: map[string]string{
+				"latency.json": `{"dashboard":{"id":3,"name":"Latency","teamId":1,"panels":[{"id":1}]}}`,
+			},
+		}
+		Expect(k8sClient.Create(ctx, cm)).To(Succeed())
+
+		dashboard := &opsv1alpha1.SysdigDashboard{
+			ObjectMeta: metav1.ObjectMeta{Name: dashboardName.Name, Namespace: namespace},
+			Spec: opsv1alpha1.SysdigDashboardSpec{
+				ConfigMapRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMapName.Name},
+					Key:                  "latency.json",
+				},
+				Sharing: &opsv1alpha1.DashboardSharingSpec{TeamRole: "edit"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, dashboard)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		team := &opsv1alpha1.SysdigTeam{}
+		Expect(k8sClient.Get(ctx, teamName, team)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, team)).To(Succeed())
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, configMapName, cm)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, cm)).To(Succeed())
+		dashboard := &opsv1alpha1.SysdigDashboard{}
+		if err := k8sClient.Get(ctx, dashboardName, dashboard); err == nil {
+			dashboard.Finalizers = nil
+			Expect(k8sClient.Update(ctx, dashboard)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, dashboard)).To(Succeed())
+		}
+	})
+
+	It("creates the dashboard in the Monitor team, keeps it in sync and deletes it", func() {
+		fake := newFakeSysdig()
+		r := &SysdigDashboardReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Sysdig: fake}
+		reconcileOnce := func() {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: dashboardName})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		reconcileOnce()
+
+		dashboard := &opsv1alpha1.SysdigDashboard{}
+		Expect(k8sClient.Get(ctx, dashboardName, dashboard)).To(Succeed())
+		Expect(dashboard.Status.Conditions).To(ConsistOf(HaveField("Status", metav1.ConditionTrue)))
+		Expect(dashboard.Status.Version).To(Equal(int64(1)))
+		created := fake.documents[dashboard.Status.ID]
+		Expect(created.TeamID).To(Equal(int64(42)))
+		Expect(created.Name).To(Equal("Latency"))
+		Expect(created.SharingSettings).To(Equal([]helpers.DashboardShare{{
+			Role: "ROLE_RESOURCE_EDIT", Member: helpers.DashboardShareMember{Type: "TEAM", ID: 42},
+		}}))
+
+		By("leaving an unchanged dashboard alone")
+		reconcileOnce()
+		Expect(fake.documents[dashboard.Status.ID].Version).To(Equal(int64(1)))
+
+		By("updating the dashboard when the ConfigMap changes")
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, configMapName, cm)).To(Succeed())
+		cm.Data["latency.json"] = `{"name":"Latency v2","panels":[]}`
+		Expect(k8sClient.Update(ctx, cm)).To(Succeed())
+		reconcileOnce()
+		Expect(k8sClient.Get(ctx, dashboardName, dashboard)).To(Succeed())
+		Expect(dashboard.Status.Version).To(Equal(int64(2)))
+		Expect(fake.documents[dashboard.Status.ID].Name).To(Equal("Latency v2"))
+		Expect(fake.documents).To(HaveLen(1))
+
+		By("reporting JSON that cannot be parsed")
+		cm.Data["latency.json"] = `[]`
+		Expect(k8sClient.Update(ctx, cm)).To(Succeed())
+		reconcileOnce()
+		Expect(k8sClient.Get(ctx, dashboardName, dashboard)).To(Succeed())
+		Expect(dashboard.Status.Conditions).To(ConsistOf(HaveField("Reason", "InvalidSpec")))
+
+		By("deleting the Sysdig dashboard with the SysdigDashboard")
+		Expect(k8sClient.Delete(ctx, dashboard)).To(Succeed())
+		reconcileOnce()
+		Expect(fake.documents).To(BeEmpty())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, dashboardName, dashboard))).To(BeTrue())
+	})
+})
@@ -21,14 +21,17 @@ import (
 
 	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
@@ -50,66 +53,77 @@ type SysdigTeamGoReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	Log    logr.Logger
+
+	// Sysdig is the Sysdig API client shared by all reconciles.
+	// A nil client means the operator was started without credentials.
+	Sysdig helpers.SysdigAPI
+
+	// ScopeAllowlist limits the labels used in spec.team.scopes.
+	// Nil means helpers.DefaultScopeAllowlist.
+	ScopeAllowlist helpers.ScopeAllowlist
+
+	// ClusterName is the kubernetes.cluster.name of this cluster. When set,
+	// team scopes are limited to it and shared with operators on other clusters.
+	ClusterName string
+
+	// TeamPolicy limits the team UI settings and permissions tenants may
+	// set. Nil means helpers.DefaultTeamPolicy.
+	TeamPolicy *helpers.TeamPolicy
+
+	// DefaultDashboards are the catalog dashboards of teams that list none.
+	// Nil means helpers.DefaultDashboards; an empty slice means no dashboards.
+	DefaultDashboards []string
+
+	// DashboardRollout controls which template version the dashboards of
+	// existing teams get. Nil means helpers.DefaultDashboardRollout.
+	DashboardRollout *helpers.DashboardRollout
+
+	// APIReader reads objects the cache does not hold, such as Secrets
+	// without the managed-by label. Nil means the client.
+	APIReader client.Reader
+
+	// TeamTokenUser is the platform service account the Sysdig client
+	// exchanges team tokens for. It adds itself to teams as needed, so
+	// memberships are never pruned of it.
+	TeamTokenUser string
 }
 
+// syncOneTeam creates or updates one product team and returns its ID.
 func (r *SysdigTeamGoReconciler) syncOneTeam(
-	apiEndpoint, token, teamName, product, description string,
-	namespaces []string,
+	ctx context.Context,
+	teamName, product, description string,
+	previous, namespaces []string,
+	clauses []helpers.ScopeClause,
+	settings helpers.TeamSettings,
 ) (int64, error) {
-	// Fetch existing teams by name filter
-	filtered, err := helpers.FetchTeams(apiEndpoint, token, teamName)
-
+	// Look up the team by its exact, case-insensitive name
+	exists, err := r.Sysdig.FindTeamByName(ctx, teamName)
 	if err != nil {
-		return 0, fmt.Errorf("fetch teams for %q: %w", teamName, err)
-	}
-
-	// Find exact, case-insensitive match
-	var exists *helpers.SysdigTeam
-	for i := range filtered {
-		if strings.EqualFold(filtered[i].Name, teamName) {
-			exists = &filtered[i]
-			break
-		}
+		return 0, fmt.Errorf("find team %q: %w", teamName, err)
 	}
 
 	// Create if missing
 	if exists == nil {
-		id, err := helpers.CreateTeam(
-			apiEndpoint,
-			token,
+		id, err := r.Sysdig.CreateTeam(
+			ctx,
 			teamName,
 			description,
 			product,
-			namespaces,
+			helpers.TeamScopes(helpers.MergeScopeExpression("", r.ClusterName, nil, namespaces, clauses)),
+			settings,
 		)
 		if err != nil {
 			return 0, fmt.Errorf("create %s team: %w", product, err)
 		}
 
 		r.Log.Info("Created Sysdig team", "product", product, "name", teamName, "id", id)
-
-		// After creating a team, create the associated dashboard.
-		if product == "monitor" && len(namespaces) > 0 {
-			dashboardApiEndpoint := os.Getenv("SYSDIG_DASHBOARD_API_ENDPOINT")
-			if dashboardApiEndpoint == "" {
-				dashboardApiEndpoint = "https://app.sysdigcloud.com" // Default if not set
-			}
-
-			r.Log.Info("Attempting to create default dashboard for new monitor team", "teamID", id)
-			// We use the first namespace in the list for the dashboard scope.
-			if err := helpers.CreateDashboard(dashboardApiEndpoint, token, id, namespaces[0]); err != nil {
-				// Log the dashboard creation error as a warning but don't fail the reconciliation,
-				// as the team itself was created successfully.
-				r.Log.Error(err, "Warning: failed to create default dashboard for team", "teamID", id)
-			} else {
-				r.Log.Info("Successfully created default dashboard for team", "teamID", id)
-			}
-		}
-
 		return id, nil
 	} else {
-		// we don't update team. as we use membership api to manage access.
+		// Memberships are managed through the membership API; only the team settings are kept in sync here.
 		r.Log.Info("Sysdig team exists, skipping create", "product", product, "name", exists.Name, "id", exists.ID)
+		if err := r.ensureTeam(ctx, exists.ID, description, previous, namespaces, clauses, settings); err != nil {
+			return 0, err
+		}
 		return exists.ID, nil
 	}
 }
@@ -118,15 +132,19 @@ func (r *SysdigTeamGoReconciler) syncOneTeam(
 // user/role pairs. It only calls SaveMembership when a user is missing
 // or has the wrong role.
 func (r *SysdigTeamGoReconciler) syncMemberships(
-	apiEndpoint, token string,
+	ctx context.Context,
 	teamID int64,
 	desired []helpers.TeamUserRole,
 	product string,
 ) error {
-	existing, err := helpers.FetchTeamMemberships(apiEndpoint, token, teamID)
+	existing, err := r.Sysdig.FetchTeamMemberships(ctx, teamID)
 	if err != nil {
 		return fmt.Errorf("fetch %s memberships: %w", product, err)
 	}
+	tokenUserID, err := r.teamTokenUserID(ctx)
+	if err != nil {
+		return fmt.Errorf("find team token user: %w", err)
+	}
 
 	// build lookup: userID -> role
 	existMap := make(map[int64]string, len(existing))
@@ -144,7 +162,7 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 		switch {
 		case !found:
 			// never had this user — just create
-			resp, err := helpers.SaveMembership(apiEndpoint, token,
+			_, err := r.Sysdig.SaveMembership(ctx,
 				teamID, d.UserID, d.Role)
 			if err != nil {
 				r.Log.Error(err, "SaveMembership failed (new)",
@@ -153,13 +171,12 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 			} else {
 				r.Log.Info("SaveMembership succeeded (new)",
 					"team", product, "teamID", teamID,
-					"userID", d.UserID, "role", d.Role,
-					"response", string(resp))
+					"userID", d.UserID, "role", d.Role)
 			}
 
 		case currentRole != d.Role:
 			// role changed — delete then re‐create (Role check)
-			if err := helpers.DeleteMembership(apiEndpoint, token, teamID, d.UserID); err != nil {
+			if err := r.Sysdig.DeleteMembership(ctx, teamID, d.UserID); err != nil {
 				r.Log.Error(err, "DeleteMembership failed",
 					"team", product, "teamID", teamID,
 					"userID", d.UserID, "oldRole", currentRole)
@@ -170,7 +187,7 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 			}
 
 			// now create with the new role
-			resp, err := helpers.SaveMembership(apiEndpoint, token,
+			_, err := r.Sysdig.SaveMembership(ctx,
 				teamID, d.UserID, d.Role)
 			if err != nil {
 				r.Log.Error(err, "SaveMembership failed (after delete)",
@@ -179,8 +196,7 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 			} else {
 				r.Log.Info("SaveMembership succeeded (after delete)",
 					"team", product, "teamID", teamID,
-					"userID", d.UserID, "role", d.Role,
-					"response", string(resp))
+					"userID", d.UserID, "role", d.Role)
 			}
 
 		default:
@@ -193,7 +209,7 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 
 	// Remove any extra users not in desired list (ID check)
 	for _, m := range existing {
-		if _, keep := desiredMap[m.UserID]; !keep {
+		if _, keep := desiredMap[m.UserID]; !keep && m.UserID != tokenUserID {
 
 			// Dustin says we can not delete ROLE_TEAM_MAGAGER once they been added, even this user does not exist
 			// , so we should let user stop adding this role.
@@ -202,7 +218,7 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 					"team", product, "teamID", teamID, "userID", m.UserID)
 				continue
 			}
-			if err := helpers.DeleteMembership(apiEndpoint, token, teamID, m.UserID); err != nil {
+			if err := r.Sysdig.DeleteMembership(ctx, teamID, m.UserID); err != nil {
 
 				r.Log.Error(err, "DeleteMembership failed",
 					"team", product, "teamID", teamID, "userID", m.UserID, "role", m.Role)
@@ -218,6 +234,10 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 // +kubebuilder:rbac:groups=monitoring.devops.gov.bc.ca,resources=sysdig-team-go,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.devops.gov.bc.ca,resources=sysdig-team-go/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=monitoring.devops.gov.bc.ca,resources=sysdig-team-go/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch
+// +kubebuilder:rbac:groups=user.openshift.io,resources=groups,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -231,9 +251,6 @@ func (r *SysdigTeamGoReconciler) syncMemberships(
 func (r *SysdigTeamGoReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx) // Use a local logger
 
-	apiEndpoint := os.Getenv("SYSDIG_API_ENDPOINT")
-	token := os.Getenv("SYSDIG_TOKEN")
-
 	logger.Info("Reconciling SysdigTeamGo", "Request.Namespace", req.Namespace, "Request.Name", req.Name)
 
 	// Step 1: Fetch the CR instance
@@ -248,30 +265,49 @@ func (r *SysdigTeamGoReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	// Handle deletion: Check if the DeletionTimestamp is set
+	// Clean up in Sysdig first; the finalizers are only removed once
+	// everything is gone, and any failure is retried with backoff.
 	if !sysdigTeam.ObjectMeta.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(&sysdigTeam, sysdigTeamFinalizer) &&
+			!controllerutil.ContainsFinalizer(&sysdigTeam, sysdigTeamFinalizerOld) {
+			return ctrl.Result{}, nil
+		}
+		if r.Sysdig == nil {
+			logger.Error(nil, "Sysdig client is not configured, keeping the finalizer until it is")
+			return ctrl.Result{}, fmt.Errorf("sysdig client is not configured, cannot clean up team")
+		}
 
-		// Remove finalizer(s)
-		controllerutil.RemoveFinalizer(&sysdigTeam, sysdigTeamFinalizerOld)
-		controllerutil.RemoveFinalizer(&sysdigTeam, sysdigTeamFinalizer)
-		r.Update(ctx, &sysdigTeam)
+		// Service accounts and channels go first, in case a team outlives the SysdigTeam.
+		if err := r.deleteServiceAccounts(ctx, &sysdigTeam); err != nil {
+			logger.Error(err, "Failed to delete team service accounts")
+			return ctrl.Result{}, err
+		}
+		if err := r.deleteNotificationChannels(ctx, &sysdigTeam); err != nil {
+			logger.Error(err, "Failed to delete notification channels")
+			return ctrl.Result{}, err
+		}
 
 		// Delete Monitor team
 		if sysdigTeam.Status.MonitorTeamID != 0 {
 			logger.Info("Deleting Monitor team", "ID", sysdigTeam.Status.MonitorTeamID)
-			if err := helpers.DeleteTeam(apiEndpoint, token, sysdigTeam.Status.MonitorTeamID); err != nil {
+			if err := r.releaseTeam(ctx, sysdigTeam.Status.MonitorTeamID, sysdigTeam.Status.ScopedNamespaces); err != nil && !helpers.IsNotFound(err) {
 				logger.Error(err, "Failed to delete Monitor team", "ID", sysdigTeam.Status.MonitorTeamID)
+				return ctrl.Result{}, err
 			}
 		}
 
 		// Delete Secure team
 		if sysdigTeam.Status.SecureTeamID != 0 {
 			logger.Info("Deleting Secure team", "ID", sysdigTeam.Status.SecureTeamID)
-			if err := helpers.DeleteTeam(apiEndpoint, token, sysdigTeam.Status.SecureTeamID); err != nil {
+			if err := r.releaseTeam(ctx, sysdigTeam.Status.SecureTeamID, sysdigTeam.Status.ScopedNamespaces); err != nil && !helpers.IsNotFound(err) {
 				logger.Error(err, "Failed to delete Secure team", "ID", sysdigTeam.Status.SecureTeamID)
+				return ctrl.Result{}, err
 			}
 		}
 
-		return ctrl.Result{}, nil // Stop reconciliation as the object is being deleted
+		controllerutil.RemoveFinalizer(&sysdigTeam, sysdigTeamFinalizerOld)
+		controllerutil.RemoveFinalizer(&sysdigTeam, sysdigTeamFinalizer)
+		return ctrl.Result{}, r.Update(ctx, &sysdigTeam) // Stop reconciliation as the object is being deleted
 	}
 
 	// Add finalizer if it doesn't exist
@@ -286,8 +322,8 @@ func (r *SysdigTeamGoReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	// Step 1.5 verify credentials
-	if apiEndpoint == "" || token == "" {
-		errMsg := "Environment variables SYSDIG_API_ENDPOINT and/or SYSDIG_TOKEN are not set"
+	if r.Sysdig == nil {
+		errMsg := "Environment variables SYSDIG_REGION or SYSDIG_API_ENDPOINT, and SYSDIG_TOKEN, are not set"
 		logger.Error(nil, errMsg) // Use logger for errors
 		sysdigTeam.Status.Conditions = []api.Condition{
 			{
@@ -332,147 +368,242 @@ func (r *SysdigTeamGoReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	// Step 0: set fact (Moved after initial checks and finalizer logic)
 	facts := helpers.SetTeamFacts(req.Namespace)
 
-	// 3) Build teamUserList from the CR's spec
-	teamUserList := make([]helpers.TeamUserRole, 0, len(sysdigTeam.Spec.Team.Users))
-	for _, u := range sysdigTeam.Spec.Team.Users {
-		teamUserList = append(teamUserList, helpers.TeamUserRole{
-			Name: u.Name, // e.g. "billy.li@gov.bc.ca"
-			Role: u.Role, // e.g. "ROLE_TEAM_EDIT"
-		})
-	}
-
-	// 4) Reconcile each user one by one
-	var teamUsersAndRoles []helpers.TeamUserRole
-	for _, tu := range teamUserList {
-		// Try to fetch by email filter
-		matched, err := helpers.FetchUsers(apiEndpoint, token, tu.Name)
-		if err != nil {
-			return ctrl.Result{}, fmt.Errorf("fetch user %q: %w", tu.Name, err)
+	// Step 2.5: apply spec.team.namespaces and check the namespaces exist
+	namespaces, missingNamespaces, err := r.resolveNamespaces(ctx, facts, sysdigTeam.Spec.Team.Namespaces, sysdigTeam.Status.Namespaces)
+	if invalid, ok := err.(*invalidSpecError); ok {
+		logger.Info("Invalid namespace configuration", "reason", invalid.Error())
+		sysdigTeam.Status.Conditions = []api.Condition{
+			{
+				Type:    "NamespaceValidation",
+				Status:  "False",
+				Reason:  "InvalidNamespaces",
+				Message: invalid.Error(),
+			},
 		}
-
-		var userID int64
-		if len(matched) > 0 {
-			// user already exists
-			userID = matched[0].ID
-			fmt.Printf("DEBUG: user %q exists as ID %d\n", tu.Name, userID)
-		} else {
-			// create new user
-			userID, err = helpers.CreateUser(apiEndpoint, token, tu.Name, tu.Role)
-			if err != nil {
-				return ctrl.Result{}, fmt.Errorf("create user %q: %w", tu.Name, err)
-			}
-			fmt.Printf("DEBUG: created user %q with ID %d\n", tu.Name, userID)
+		if err := r.Status().Update(ctx, &sysdigTeam); err != nil {
+			logger.Error(err, "Failed to update SysdigTeamGo status for invalid namespaces")
 		}
-
-		// build the final list
-		teamUsersAndRoles = append(teamUsersAndRoles, helpers.TeamUserRole{
-			Name:   tu.Name,
-			Role:   tu.Role,
-			UserID: userID,
-		})
+		// Namespaces are not watched, so check again later in case a missing one was created.
+		return ctrl.Result{RequeueAfter: namespaceRecheckAfter}, nil
+	} else if err != nil {
+		logger.Error(err, "Failed to resolve team namespaces")
+		return ctrl.Result{}, err
 	}
+	facts.SetNamespaces(namespaces)
+	sysdigTeam.Status.Namespaces = namespaces
 
-	// 5)----- MONITOR TEAM -----
-	monitorTeamID, err := r.syncOneTeam(
-		apiEndpoint,
-		token,
-		facts.ContainerTeamName,
-		"monitor",
-		sysdigTeam.Spec.Team.Description,
-		facts.Namespaces,
-	)
-	if err != nil {
-		logger.Error(err, "Failed to sync Monitor team")
-		// Update status with error condition before returning
+	// Step 2.6: check spec.team.scopes against the allowlist
+	monitorClauses, secureClauses, err := r.teamScopeClauses(sysdigTeam.Spec.Team.Scopes)
+	if invalid, ok := err.(*invalidSpecError); ok {
+		logger.Info("Invalid scope configuration", "reason", invalid.Error())
 		sysdigTeam.Status.Conditions = []api.Condition{
-			{Type: "Ready", Status: "False", Reason: "TeamSyncFailed", Message: "Failed to sync Monitor team: " + err.Error()},
+			{
+				Type:    "ScopeValidation",
+				Status:  "False",
+				Reason:  "InvalidScope",
+				Message: invalid.Error(),
+			},
 		}
-		if statusUpdateErr := r.Status().Update(ctx, &sysdigTeam); statusUpdateErr != nil {
-			logger.Error(statusUpdateErr, "Failed to update status for Monitor team sync failure")
+		if err := r.Status().Update(ctx, &sysdigTeam); err != nil {
+			logger.Error(err, "Failed to update SysdigTeamGo status for invalid scopes")
 		}
-		return ctrl.Result{}, err
+		return ctrl.Result{}, nil // Don't requeue, the spec has to change
 	}
-	sysdigTeam.Status.MonitorTeamID = monitorTeamID // Store MonitorTeamID
 
-	if err := r.syncMemberships(apiEndpoint, token, monitorTeamID, teamUsersAndRoles, "monitor"); err != nil {
-		logger.Error(err, "Failed to sync Monitor team memberships")
+	// Step 2.7: check spec.team.dashboards against the dashboard catalog
+	dashboards, err := r.teamDashboards(sysdigTeam.Spec.Team.Dashboards)
+	dashboardReason := "UnknownDashboard"
+	if err == nil {
+		dashboards, err = dashboardsInScope(ctx, dashboards, facts, len(sysdigTeam.Spec.Team.Dashboards) > 0)
+		dashboardReason = "InvalidSpec"
+	}
+	if invalid, ok := err.(*invalidSpecError); ok {
+		logger.Info("Invalid dashboard configuration", "reason", invalid.Error())
 		sysdigTeam.Status.Conditions = []api.Condition{
-			{Type: "Ready", Status: "False", Reason: "MembershipSyncFailed", Message: "Failed to sync Monitor team memberships: " + err.Error()},
+			{
+				Type:    "DashboardValidation",
+				Status:  "False",
+				Reason:  dashboardReason,
+				Message: invalid.Error(),
+			},
 		}
-		if statusUpdateErr := r.Status().Update(ctx, &sysdigTeam); statusUpdateErr != nil {
-			logger.Error(statusUpdateErr, "Failed to update status for Monitor team membership sync failure")
+		if err := r.Status().Update(ctx, &sysdigTeam); err != nil {
+			logger.Error(err, "Failed to update SysdigTeamGo status for invalid dashboards")
 		}
-		return ctrl.Result{}, err
+		return ctrl.Result{}, nil // Don't requeue, the spec has to change
 	}
 
-	// 6) ----- Secure TEAM -----
-	secureTeamID, err := r.syncOneTeam(
-		apiEndpoint,
-		token,
-		facts.ContainerSecureTeamName,
-		"secure",
-		sysdigTeam.Spec.Team.Description,
-		facts.Namespaces,
-	)
+	// 3) Work out the product teams and who belongs to each. Users listed in
+	// the spec override the roles derived from Groups and RoleBindings.
+	derived, err := r.membershipUsers(ctx, sysdigTeam.Spec.Team.Membership, namespaces)
 	if err != nil {
-		logger.Error(err, "Failed to sync Secure team")
-		sysdigTeam.Status.Conditions = []api.Condition{
-			{Type: "Ready", Status: "False", Reason: "TeamSyncFailed", Message: "Failed to sync Secure team: " + err.Error()},
+		return r.failReconcile(ctx, &sysdigTeam, "MembershipSourceFailed", "Failed to read team membership sources", err)
+	}
+	sharedUsers := append(derived, sysdigTeam.Spec.Team.Users...)
+
+	products := []productTeam{
+		{
+			product: "monitor",
+			label:   "Monitor",
+			name:    facts.ContainerTeamName,
+			spec:    sysdigTeam.Spec.Team.Monitor,
+			clauses: monitorClauses,
+			teamID:  &sysdigTeam.Status.MonitorTeamID,
+			status:  &sysdigTeam.Status.Monitor,
+		},
+		{
+			product: "secure",
+			label:   "Secure",
+			name:    facts.ContainerSecureTeamName,
+			spec:    sysdigTeam.Spec.Team.Secure,
+			clauses: secureClauses,
+			teamID:  &sysdigTeam.Status.SecureTeamID,
+			status:  &sysdigTeam.Status.Secure,
+		},
+	}
+
+	// 4) Reconcile each user of an enabled team one by one
+	userIDs := map[string]int64{}
+	for _, p := range products {
+		if !p.enabled() {
+			continue
 		}
-		if statusUpdateErr := r.Status().Update(ctx, &sysdigTeam); statusUpdateErr != nil {
-			logger.Error(statusUpdateErr, "Failed to update status for Secure team sync failure")
+		for _, tu := range p.users(sharedUsers) {
+			key := strings.ToLower(strings.TrimSpace(tu.Name))
+			if _, done := userIDs[key]; done {
+				continue
+			}
+
+			// Look up the user by its exact, case-insensitive email
+			matched, err := r.Sysdig.FindUserByEmail(ctx, tu.Name)
+			if err != nil {
+				return r.failReconcile(ctx, &sysdigTeam, "UserSyncFailed", fmt.Sprintf("Failed to find user %q", tu.Name), err)
+			}
+
+			var userID int64
+			if matched != nil {
+				// user already exists
+				userID = matched.ID
+				logger.V(1).Info("Found existing Sysdig user", "userID", userID)
+			} else {
+				// create new user
+				userID, err = r.Sysdig.CreateUser(ctx, tu.Name, tu.Role)
+				if err != nil {
+					return r.failReconcile(ctx, &sysdigTeam, "UserSyncFailed", fmt.Sprintf("Failed to create user %q", tu.Name), err)
+				}
+				logger.Info("Created Sysdig user", "userID", userID)
+			}
+			userIDs[key] = userID
 		}
-		return ctrl.Result{}, err
 	}
-	sysdigTeam.Status.SecureTeamID = secureTeamID // Store SecureTeamID
 
-	logger.Info("Successfully synced teams", "MonitorTeamID", monitorTeamID, "SecureTeamID", secureTeamID)
+	// 5) Create, update or delete each product team on its own
+	for _, p := range products {
+		if !p.enabled() {
+			if *p.teamID != 0 {
+				// Service accounts and channels go first, while their team still exists.
+				if err := r.deleteProductServiceAccounts(ctx, &sysdigTeam, p.product); err != nil {
+					return r.failReconcile(ctx, &sysdigTeam, "ServiceAccountSyncFailed",
+						fmt.Sprintf("Failed to delete %s team service accounts", p.label), err)
+				}
+				if p.product == "monitor" {
+					if err := r.deleteNotificationChannels(ctx, &sysdigTeam); err != nil {
+						return r.failReconcile(ctx, &sysdigTeam, "NotificationChannelSyncFailed", "Failed to delete notification channels", err)
+					}
+					sysdigTeam.Status.NotificationChannels = nil
+				}
+
+				logger.Info("Product disabled, deleting its team", "product", p.product, "ID", *p.teamID)
+				if err := r.releaseTeam(ctx, *p.teamID, sysdigTeam.Status.ScopedNamespaces); err != nil && !helpers.IsNotFound(err) {
+					return r.failReconcile(ctx, &sysdigTeam, "TeamSyncFailed", fmt.Sprintf("Failed to delete %s team", p.label), err)
+				}
+				*p.teamID = 0
+			}
+			*p.status = nil
+			continue
+		}
 
-	if err := r.syncMemberships(apiEndpoint, token, secureTeamID, teamUsersAndRoles, "secure"); err != nil {
-		logger.Error(err, "Failed to sync Secure team memberships")
-		sysdigTeam.Status.Conditions = []api.Condition{
-			{Type: "Ready", Status: "False", Reason: "MembershipSyncFailed", Message: "Failed to sync Secure team memberships: " + err.Error()},
+		settings, clamped := p.settings(r.teamPolicy())
+		if len(clamped) > 0 {
+			logger.Info("Team settings clamped by platform policy", "product", p.product, "clamped", clamped)
 		}
-		if statusUpdateErr := r.Status().Update(ctx, &sysdigTeam); statusUpdateErr != nil {
-			logger.Error(statusUpdateErr, "Failed to update status for Secure team membership sync failure")
+
+		teamID, err := r.syncOneTeam(
+			ctx,
+			p.name,
+			p.product,
+			p.description(sysdigTeam.Spec.Team.Description),
+			sysdigTeam.Status.ScopedNamespaces,
+			facts.Namespaces,
+			p.clauses,
+			settings,
+		)
+		if err != nil {
+			return r.failReconcile(ctx, &sysdigTeam, "TeamSyncFailed", fmt.Sprintf("Failed to sync %s team", p.label), err)
+		}
+		*p.teamID = teamID
+		*p.status = productStatus(settings, clamped)
+
+		var teamUsersAndRoles []helpers.TeamUserRole
+		for _, tu := range p.users(sharedUsers) {
+			tu.UserID = userIDs[strings.ToLower(strings.TrimSpace(tu.Name))]
+			teamUsersAndRoles = append(teamUsersAndRoles, tu)
+		}
+		if err := r.syncMemberships(ctx, teamID, teamUsersAndRoles, p.product); err != nil {
+			return r.failReconcile(ctx, &sysdigTeam, "MembershipSyncFailed", fmt.Sprintf("Failed to sync %s team memberships", p.label), err)
 		}
-		return ctrl.Result{}, err
 	}
-	// 7) Assign or update memberships for each user in both teams - THIS SECTION SEEMS REDUNDANT
-	// The syncMemberships function already ensures the desired state.
-	// The loop below re-applies SaveMembership, which might be okay for idempotency but syncMemberships should handle it.
-	// I will comment it out for now as syncMemberships is designed to be the source of truth for memberships.
-	/*
-		for _, m := range teamUsersAndRoles {
-			// Monitor team membership
-			_, Monitorerr := helpers.SaveMembership(
-				apiEndpoint,
-				token,
-				monitorTeamID,
-				m.UserID,
-				m.Role,
-			)
-			if Monitorerr != nil {
-				r.Log.Error(err, "SaveMembership failed for monitor team",
-					"teamID", monitorTeamID, "userID", m.UserID, "role", m.Role)
-			}
 
-			// Secure team membership
-			_, Secureerr := helpers.SaveMembership(
-				apiEndpoint,
-				token,
-				secureTeamID,
-				m.UserID,
-				m.Role,
-			)
-			if Secureerr != nil {
-				r.Log.Error(err, "SaveMembership failed for secure team",
-					"teamID", secureTeamID, "userID", m.UserID, "role", m.Role)
-			}
+	sysdigTeam.Status.ScopedNamespaces = facts.Namespaces
+
+	logger.Info("Successfully synced teams",
+		"MonitorTeamID", sysdigTeam.Status.MonitorTeamID, "SecureTeamID", sysdigTeam.Status.SecureTeamID)
 
-			r.Log.Info("finish user", "name", m.Name)
+	// 6) Issue, rotate and clean up team service account tokens
+	rotateIn, err := r.syncServiceAccounts(ctx, &sysdigTeam)
+	if err != nil {
+		return r.failReconcile(ctx, &sysdigTeam, "ServiceAccountSyncFailed", "Failed to sync team service accounts", err)
+	}
+
+	// 6b) Keep the Monitor team's notification channels in sync
+	if err := r.syncNotificationChannels(ctx, &sysdigTeam); err != nil {
+		if invalid, ok := err.(*invalidSpecError); ok {
+			logger.Info("Invalid notification channel configuration", "reason", invalid.Error())
+			sysdigTeam.Status.Conditions = []api.Condition{
+				{
+					Type:    "NotificationChannelValidation",
+					Status:  "False",
+					Reason:  "InvalidNotificationChannel",
+					Message: invalid.Error(),
+				},
+			}
+			if err := r.Status().Update(ctx, &sysdigTeam); err != nil {
+				logger.Error(err, "Failed to update SysdigTeamGo status for invalid notification channels")
+			}
+			return ctrl.Result{}, nil // Don't requeue, the spec has to change
 		}
-	*/
+		return r.failReconcile(ctx, &sysdigTeam, "NotificationChannelSyncFailed", "Failed to sync notification channels", err)
+	}
+
+	// 6c) Provision the Monitor team's catalog dashboards. A failure does not
+	// hold up the rest of the team; it is reported in DashboardsReady and
+	// retried with backoff.
+	dashboardsReady := api.Condition{
+		Type:    "DashboardsReady",
+		Status:  "True",
+		Reason:  "Provisioned",
+		Message: "Catalog dashboards are provisioned",
+	}
+	var dashboardResult ctrl.Result
+	var dashboardErr error
+	if err := r.syncDashboards(ctx, &sysdigTeam, dashboards, r.dashboardContext(facts, sysdigTeam.Spec.Team.ResourceQuotas)); err != nil {
+		var reason string
+		reason, dashboardResult, dashboardErr = classifySysdigError(err, "DashboardSyncFailed")
+		logger.Error(err, "Failed to provision dashboards", "reason", reason)
+		dashboardsReady.Status = "False"
+		dashboardsReady.Reason = reason
+		dashboardsReady.Message = "Failed to provision dashboards: " + err.Error()
+	}
 
 	// Update status to Ready
 	sysdigTeam.Status.Conditions = []api.Condition{
@@ -482,14 +613,28 @@ func (r *SysdigTeamGoReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			Reason:  "Reconciled",
 			Message: "Sysdig teams and memberships reconciled successfully",
 		},
+		dashboardsReady,
+	}
+	if len(missingNamespaces) > 0 {
+		sysdigTeam.Status.Conditions = append(sysdigTeam.Status.Conditions, api.Condition{
+			Type:   "NamespaceValidation",
+			Status: "False",
+			Reason: "MissingNamespaces",
+			Message: fmt.Sprintf("Namespaces %s do not exist and are kept in the team scope; exclude them in spec.team.namespaces to drop them",
+				strings.Join(missingNamespaces, ", ")),
+		})
 	}
 	if err := r.Status().Update(ctx, &sysdigTeam); err != nil {
 		logger.Error(err, "Failed to update SysdigTeam status to Ready")
 		return ctrl.Result{}, err
 	}
+	if dashboardErr != nil {
+		// Returning the error requeues with the controller's exponential backoff.
+		return ctrl.Result{}, dashboardErr
+	}
 
 	logger.Info("Successfully reconciled SysdigTeam")
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: sooner(rotateIn, dashboardResult.RequeueAfter)}, nil
 }
 
 // containsString checks if a slice of strings contains a specific string.
@@ -516,7 +661,20 @@ func removeString(slice []string, s string) []string {
 // SetupWithManager sets up the controller with the Manager.
 func (r *SysdigTeamGoReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Log = ctrl.Log.WithName("controllers").WithName("SysdigTeam")
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&opsv1alpha1.SysdigTeam{}).
-		Complete(r)
+		// One watch covers the token Secrets of a team and the Secrets its
+		// webhook URLs are read from, as far as they carry the managed-by label.
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.teamsForSecret)).
+		Watches(&rbacv1.RoleBinding{}, handler.EnqueueRequestsFromMapFunc(r.teamsForRoleBinding))
+
+	// Groups are only served on OpenShift.
+	if _, err := mgr.GetRESTMapper().RESTMapping(groupGVK.GroupKind(), groupGVK.Version); err == nil {
+		group := &unstructured.Unstructured{}
+		group.SetGroupVersionKind(groupGVK)
+		b = b.Watches(group, handler.EnqueueRequestsFromMapFunc(r.teamsForGroup))
+	} else {
+		r.Log.Info("user.openshift.io Groups are not available, membership is not re-synced on Group changes")
+	}
+	return b.Complete(r)
 }
@@ -18,9 +18,13 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -28,8 +32,282 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	opsv1alpha1 "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
 )
 
+// fakeSysdig is an in-memory helpers.SysdigAPI used by the controller tests.
+type fakeSysdig struct {
+	nextID      int64
+	teams       map[string]int64
+	scopes      map[int64]string
+	descs       map[int64]string
+	settings    map[int64]helpers.TeamSettings
+	users       map[string]int64
+	memberships map[int64]map[int64]string
+	// dashboards maps team IDs to the catalog dashboards created in them.
+	dashboards map[int64][]string
+	// accounts maps service account IDs to their team ID.
+	accounts map[int64]int64
+	channels map[int64]helpers.NotificationChannel
+	alerts   map[int64]helpers.Alert
+	// documents holds dashboards created from a document, by ID.
+	documents map[int64]helpers.Dashboard
+	// deleteErr, when set, fails every notification channel delete.
+	deleteErr error
+}
+
+func newFakeSysdig() *fakeSysdig {
+	return &fakeSysdig{
+		nextID:      100,
+		teams:       map[string]int64{},
+		scopes:      map[int64]string{},
+		descs:       map[int64]string{},
+		settings:    map[int64]helpers.TeamSettings{},
+		accounts:    map[int64]int64{},
+		channels:    map[int64]helpers.NotificationChannel{},
+		alerts:      map[int64]helpers.Alert{},
+		documents:   map[int64]helpers.Dashboard{},
+		dashboards:  map[int64][]string{},
+		users:       map[string]int64{},
+		memberships: map[int64]map[int64]string{},
+	}
+}
+
+func (f *fakeSysdig) id() int64 {
+	f.nextID++
+	return f.nextID
+}
+
+func (f *fakeSysdig) FindTeamByName(_ context.Context, name string) (*helpers.SysdigTeam, error) {
+	if id, ok := f.teams[name]; ok {
+		return &helpers.SysdigTeam{ID: id, Name: name}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeSysdig) GetTeam(_ context.Context, teamID int64) (*helpers.TeamDetails, error) {
+	for name, id := range f.teams {
+		if id == teamID {
+			return &helpers.TeamDetails{
+				ID:                        id,
+				Name:                      name,
+				Description:               f.descs[id],
+				Scopes:                    []helpers.Scope{{Type: "AGENT", Expression: f.scopes[id]}},
+				UISettings:                f.settings[id].UI,
+				AdditionalTeamPermissions: f.settings[id].Permissions,
+			}, nil
+		}
+	}
+	return nil, &helpers.SysdigAPIError{Operation: "GetTeam", StatusCode: 404}
+}
+
+func (f *fakeSysdig) CreateTeam(
+	_ context.Context,
+	name, description, _ string,
+	scopes []helpers.Scope,
+	settings helpers.TeamSettings,
+) (int64, error) {
+	f.teams[name] = f.id()
+	f.descs[f.teams[name]] = description
+	f.settings[f.teams[name]] = settings
+	for _, s := range scopes {
+		if s.Type == "AGENT" {
+			f.scopes[f.teams[name]] = s.Expression
+		}
+	}
+	return f.teams[name], nil
+}
+
+func (f *fakeSysdig) UpdateTeam(_ context.Context, team *helpers.TeamDetails) error {
+	f.descs[team.ID] = team.Description
+	f.settings[team.ID] = helpers.TeamSettings{UI: team.UISettings, Permissions: team.AdditionalTeamPermissions}
+	for _, s := range team.Scopes {
+		if s.Type == "AGENT" {
+			f.scopes[team.ID] = s.Expression
+		}
+	}
+	return nil
+}
+
+func (f *fakeSysdig) DeleteTeam(_ context.Context, teamID int64) error {
+	for name, id := range f.teams {
+		if id == teamID {
+			delete(f.teams, name)
+		}
+	}
+	return nil
+}
+
+func (f *fakeSysdig) FindUserByEmail(_ context.Context, email string) (*helpers.SysdigUser, error) {
+	if id, ok := f.users[email]; ok {
+		return &helpers.SysdigUser{ID: id, Email: email}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeSysdig) CreateUser(_ context.Context, email, _ string) (int64, error) {
+	f.users[email] = f.id()
+	return f.users[email], nil
+}
+
+func (f *fakeSysdig) FetchTeamMemberships(_ context.Context, teamID int64) ([]helpers.TeamMembership, error) {
+	var out []helpers.TeamMembership
+	for userID, role := range f.memberships[teamID] {
+		out = append(out, helpers.TeamMembership{UserID: userID, Role: role})
+	}
+	return out, nil
+}
+
+func (f *fakeSysdig) SaveMembership(_ context.Context, teamID, userID int64, role string) (string, error) {
+	if f.memberships[teamID] == nil {
+		f.memberships[teamID] = map[int64]string{}
+	}
+	f.memberships[teamID][userID] = role
+	return "", nil
+}
+
+func (f *fakeSysdig) DeleteMembership(_ context.Context, teamID, userID int64) error {
+	delete(f.memberships[teamID], userID)
+	return nil
+}
+
+func (f *fakeSysdig) CreateServiceAccount(
+	_ context.Context,
+	teamID int64,
+	name, role string,
+	_ time.Time,
+) (*helpers.ServiceAccount, error) {
+	id := f.id()
+	f.accounts[id] = teamID
+	return &helpers.ServiceAccount{ID: id, Name: name, TeamRole: role, APIKey: fmt.Sprintf("key-%d", id)}, nil
+}
+
+func (f *fakeSysdig) DeleteServiceAccount(_ context.Context, teamID, accountID int64) error {
+	if f.accounts[accountID] != teamID {
+		return &helpers.SysdigAPIError{Operation: "DeleteServiceAccount", StatusCode: 404}
+	}
+	delete(f.accounts, accountID)
+	return nil
+}
+
+func (f *fakeSysdig) CreateNotificationChannel(_ context.Context, channel helpers.NotificationChannel) (int64, error) {
+	channel.ID = f.id()
+	f.channels[channel.ID] = channel
+	return channel.ID, nil
+}
+
+func (f *fakeSysdig) GetNotificationChannel(_ context.Context, id int64) (*helpers.NotificationChannel, error) {
+	channel, ok := f.channels[id]
+	if !ok {
+		return nil, &helpers.SysdigAPIError{Operation: "GetNotificationChannel", StatusCode: 404}
+	}
+	return &channel, nil
+}
+
+func (f *fakeSysdig) UpdateNotificationChannel(_ context.Context, channel *helpers.NotificationChannel) error {
+	f.channels[channel.ID] = *channel
+	return nil
+}
+
+func (f *fakeSysdig) DeleteNotificationChannel(_ context.Context, id int64) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	if _, ok := f.channels[id]; !ok {
+		return &helpers.SysdigAPIError{Operation: "DeleteNotificationChannel", StatusCode: 404}
+	}
+	delete(f.channels, id)
+	return nil
+}
+
+func (f *fakeSysdig) CreateAlert(_ context.Context, alert helpers.Alert) (*helpers.Alert, error) {
+	alert.ID, alert.Version = f.id(), 1
+	f.alerts[alert.ID] = alert
+	return &alert, nil
+}
+
+func (f *fakeSysdig) GetAlert(_ context.Context, id int64) (*helpers.Alert, error) {
+	alert, ok := f.alerts[id]
+	if !ok {
+		return nil, &helpers.SysdigAPIError{Operation: "GetAlert", StatusCode: 404}
+	}
+	return &alert, nil
+}
+
+func (f *fakeSysdig) UpdateAlert(_ context.Context, alert *helpers.Alert) (*helpers.Alert, error) {
+	if f.alerts[alert.ID].Version != alert.Version {
+		return nil, &helpers.SysdigAPIError{Operation: "UpdateAlert", StatusCode: 409}
+	}
+	updated := *alert
+	updated.Version++
+	f.alerts[alert.ID] = updated
+	return &updated, nil
+}
+
+func (f *fakeSysdig) DeleteAlert(_ context.Context, id int64) error {
+	if _, ok := f.alerts[id]; !ok {
+		return &helpers.SysdigAPIError{Operation: "DeleteAlert", StatusCode: 404}
+	}
+	delete(f.alerts, id)
+	return nil
+}
+
+func (f *fakeSysdig) CreateDashboard(
+	ctx context.Context,
+	t helpers.DashboardTemplate,
+	data helpers.DashboardContext,
+) (*helpers.Dashboard, error) {
+	d, err := t.Document(data)
+	if err != nil {
+		return nil, err
+	}
+	f.dashboards[data.TeamID] = append(f.dashboards[data.TeamID], t.Name)
+	return f.CreateTeamDashboard(ctx, d)
+}
+
+func (f *fakeSysdig) CreateTeamDashboard(_ context.Context, d *helpers.Dashboard) (*helpers.Dashboard, error) {
+	created := *d
+	created.ID, created.Version = f.id(), 1
+	f.documents[created.ID] = created
+	return &created, nil
+}
+
+func (f *fakeSysdig) GetDashboard(_ context.Context, id int64) (*helpers.Dashboard, error) {
+	d, ok := f.documents[id]
+	if !ok {
+		return nil, &helpers.SysdigAPIError{Operation: "GetDashboard", StatusCode: 404}
+	}
+	return &d, nil
+}
+
+func (f *fakeSysdig) ListDashboards(_ context.Context, teamID int64) ([]helpers.Dashboard, error) {
+	var out []helpers.Dashboard
+	for _, d := range f.documents {
+		if d.TeamID == teamID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSysdig) UpdateDashboard(_ context.Context, d *helpers.Dashboard) (*helpers.Dashboard, error) {
+	if f.documents[d.ID].Version != d.Version {
+		return nil, &helpers.SysdigAPIError{Operation: "UpdateDashboard", StatusCode: 409}
+	}
+	updated := *d
+	updated.Version++
+	f.documents[d.ID] = updated
+	return &updated, nil
+}
+
+func (f *fakeSysdig) DeleteDashboard(_ context.Context, id int64) error {
+	if _, ok := f.documents[id]; !ok {
+		return &helpers.SysdigAPIError{Operation: "DeleteDashboard", StatusCode: 404}
+	}
+	delete(f.documents, id)
+	return nil
+}
+
 var _ = Describe("SysdigTeamGo Controller", func() {
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
@@ -71,6 +349,7 @@ var _ = Describe("SysdigTeamGo Controller", func() {
 			controllerReconciler := &SysdigTeamGoReconciler{
 				Client: k8sClient,
 				Scheme: k8sClient.Scheme(),
+				Sysdig: newFakeSysdig(),
 			}
 
 			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
@@ -81,4 +360,1006 @@ var _ = Describe("SysdigTeamGo Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When reconciling a resource in a -tools namespace", func() {
+		const resourceName = "abc123-team"
+		const namespace = "abc123-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			// The catalog dashboards render the -prod namespace.
+			for _, name := range []string{namespace, "abc123-prod"} {
+				ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				err := k8sClient.Create(ctx, ns)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						Description: "abc123 team",
+						Users: []opsv1alpha1.UserSpec{
+							{Name: "jane.doe@gov.bc.ca", Role: "ROLE_TEAM_EDIT"},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("should create both teams and memberships through the Sysdig client", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+
+			// The first pass only adds the finalizer.
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.MonitorTeamID).To(Equal(fake.teams["abc123-team"]))
+			Expect(resource.Status.SecureTeamID).To(Equal(fake.teams["abc123-team-secure"]))
+			Expect(fake.dashboards).To(HaveLen(1))
+			Expect(fake.dashboards).To(HaveKeyWithValue(resource.Status.MonitorTeamID, helpers.DefaultDashboards))
+
+			userID := fake.users["jane.doe@gov.bc.ca"]
+			Expect(fake.memberships[resource.Status.MonitorTeamID]).To(HaveKeyWithValue(userID, "ROLE_TEAM_EDIT"))
+			Expect(fake.memberships[resource.Status.SecureTeamID]).To(HaveKeyWithValue(userID, "ROLE_TEAM_EDIT"))
+		})
+
+		It("keeps the team token user in the team", func() {
+			fake := newFakeSysdig()
+			tokenUser, _ := fake.CreateUser(ctx, "platform@gov.bc.ca", "ROLE_TEAM_EDIT")
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client:        k8sClient,
+				Scheme:        k8sClient.Scheme(),
+				Sysdig:        fake,
+				TeamTokenUser: "platform@gov.bc.ca",
+			}
+			reconcileTeam := func() {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			reconcileTeam()
+			reconcileTeam()
+			monitorID := fake.teams["abc123-team"]
+			// The client adds the user the first time it needs a team token.
+			_, err := fake.SaveMembership(ctx, monitorID, tokenUser, helpers.DefaultTeamTokenRole)
+			Expect(err).NotTo(HaveOccurred())
+
+			reconcileTeam()
+			Expect(fake.memberships[monitorID]).To(HaveKeyWithValue(tokenUser, helpers.DefaultTeamTokenRole))
+		})
+	})
+
+	Context("When spec.team.dashboards picks catalog dashboards", func() {
+		const resourceName = "bcd890-team"
+		const namespace = "bcd890-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			// The catalog dashboards render the -prod namespace.
+			for _, name := range []string{namespace, "bcd890-prod"} {
+				ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				err := k8sClient.Create(ctx, ns)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						Dashboards: []string{"resource-allocation", "workload-stability-scaling"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("creates the listed dashboards instead of the defaults", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+
+			// The first pass only adds the finalizer.
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(fake.dashboards[resource.Status.MonitorTeamID]).To(Equal(
+				[]string{"resource-allocation", "workload-stability-scaling"}))
+
+			By("rejecting dashboards that are not in the catalog")
+			resource.Spec.Team.Dashboards = []string{"nope"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Conditions).To(ConsistOf(HaveField("Reason", "UnknownDashboard")))
+
+			By("rejecting dashboards that need a prod namespace outside the team scope")
+			resource.Spec.Team.Dashboards = []string{"resource-allocation"}
+			resource.Spec.Team.Namespaces = &opsv1alpha1.NamespaceSpec{Excluded: []string{"bcd890-prod"}}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Conditions).To(ConsistOf(And(
+				HaveField("Reason", "InvalidSpec"), HaveField("Message", ContainSubstring("bcd890-prod")))))
+		})
+
+		It("recreates deleted dashboards and adopts untracked ones", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			reconcileOnce := func() {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			// The first pass only adds the finalizer.
+			reconcileOnce()
+			reconcileOnce()
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Conditions).To(ContainElement(And(
+				HaveField("Type", "DashboardsReady"), HaveField("Status", metav1.ConditionTrue))))
+			Expect(resource.Status.Dashboards).To(HaveLen(2))
+			allocation, stability := resource.Status.Dashboards[0], resource.Status.Dashboards[1]
+
+			By("recreating a dashboard deleted in Sysdig")
+			delete(fake.documents, allocation.ID)
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Dashboards[0].ID).NotTo(Equal(allocation.ID))
+			Expect(resource.Status.Dashboards[1]).To(Equal(stability))
+			Expect(fake.documents).To(HaveLen(2))
+
+			By("adopting a dashboard created before dashboards were tracked")
+			untracked := fake.documents[stability.ID]
+			untracked.Description = ""
+			fake.documents[stability.ID] = untracked
+			resource.Status.Dashboards = nil
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+			reconcileOnce()
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Dashboards[1]).To(Equal(stability))
+			Expect(fake.documents).To(HaveLen(2))
+			Expect(fake.documents[stability.ID].Description).To(BeEmpty())
+		})
+
+		It("counts an unmarked dashboard as version 1 and leaves its upgrade to the rollout", func() {
+			data := helpers.NewDashboardContext(helpers.SetTeamFacts(namespace), "")
+			t, err := helpers.LookupDashboardTemplate("resource-allocation")
+			Expect(err).NotTo(HaveOccurred())
+			doc, err := t.Document(data)
+			Expect(err).NotTo(HaveOccurred())
+			unmarked := helpers.Dashboard{ID: 7, Name: doc.Name}
+
+			s, found, err := findDashboard(nil, []helpers.Dashboard{unmarked}, "resource-allocation", data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(s).To(Equal(opsv1alpha1.DashboardStatus{Template: "resource-allocation", ID: 7, Version: 1}))
+
+			none := helpers.DashboardRollout{Percent: 0}
+			Expect(none.Target(resourceName, "resource-allocation", s.Version)).To(Equal(s.Version))
+		})
+
+		It("moves dashboards to a pinned template version in place", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+				DashboardRollout: &helpers.DashboardRollout{
+					Percent: 0,
+					Pins:    map[string]int{"resource-allocation": 1},
+				},
+			}
+			reconcileOnce := func() {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			// The first pass only adds the finalizer.
+			reconcileOnce()
+			reconcileOnce()
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Dashboards).To(HaveLen(2))
+			allocation := resource.Status.Dashboards[0]
+			Expect(allocation.Template).To(Equal("resource-allocation"))
+			Expect(allocation.Version).To(Equal(1))
+			Expect(fake.documents[allocation.ID].Description).To(
+				ContainSubstring(helpers.DashboardMarker("resource-allocation", 1)))
+
+			By("rolling back a dashboard a newer operator upgraded")
+			upgraded := fake.documents[allocation.ID]
+			upgraded.Description = helpers.DashboardMarker("resource-allocation", 2)
+			fake.documents[allocation.ID] = upgraded
+			resource.Status.Dashboards[0].Version = 2
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+			reconcileOnce()
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Dashboards[0]).To(Equal(allocation))
+			rolledBack := fake.documents[allocation.ID]
+			Expect(rolledBack.Version).To(Equal(int64(2)))
+			Expect(rolledBack.Description).To(ContainSubstring(helpers.DashboardMarker("resource-allocation", 1)))
+			Expect(fake.documents).To(HaveLen(2))
+		})
+	})
+
+	Context("When spec.team.namespaces adjusts the team scope", func() {
+		const resourceName = "def456-team"
+		const namespace = "def456-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			for _, name := range []string{namespace, "def456-dev", "def456-sandbox", "other-prod"} {
+				ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+				err := k8sClient.Create(ctx, ns)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						Description: "def456 team",
+						Namespaces: &opsv1alpha1.NamespaceSpec{
+							Extra:    []string{"def456-sandbox"},
+							Excluded: []string{"def456-dev"},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		reconcileOnce := func(r *SysdigTeamGoReconciler) {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("scopes the teams to existing, non-excluded namespaces and keeps them in sync", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			reconcileOnce(controllerReconciler)
+			reconcileOnce(controllerReconciler)
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Namespaces).To(Equal([]string{"def456-tools", "def456-sandbox"}))
+			Expect(fake.scopes[resource.Status.MonitorTeamID]).To(Equal(
+				`kubernetes.namespace.name in ("def456-tools","def456-sandbox")`))
+
+			By("dropping the exclusion, the existing teams are updated")
+			resource.Spec.Team.Namespaces.Excluded = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			reconcileOnce(controllerReconciler)
+			Expect(fake.scopes[resource.Status.SecureTeamID]).To(Equal(
+				`kubernetes.namespace.name in ("def456-tools","def456-dev","def456-sandbox")`))
+
+			By("keeping a default namespace of the current scope that is gone")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Status.Namespaces = append(resource.Status.Namespaces, "def456-prod")
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+			reconcileOnce(controllerReconciler)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Namespaces).To(Equal([]string{"def456-tools", "def456-dev", "def456-prod", "def456-sandbox"}))
+			Expect(resource.Status.Conditions).To(ContainElement(And(
+				HaveField("Reason", "MissingNamespaces"), HaveField("Message", ContainSubstring("def456-prod")))))
+			resource.Spec.Team.Namespaces.Excluded = []string{"def456-prod"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			reconcileOnce(controllerReconciler)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Namespaces).To(Equal([]string{"def456-tools", "def456-dev", "def456-sandbox"}))
+			Expect(resource.Status.Conditions).NotTo(ContainElement(HaveField("Reason", "MissingNamespaces")))
+
+			By("adding a Monitor scope clause, only the Monitor team is narrowed")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Team.Scopes = &opsv1alpha1.ScopeSpec{
+				Monitor: []opsv1alpha1.ScopeClause{
+					{Label: "kubernetes.cluster.name", Operator: "in", Values: []string{"silver"}},
+				},
+			}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			reconcileOnce(controllerReconciler)
+			Expect(fake.scopes[resource.Status.MonitorTeamID]).To(Equal(
+				`kubernetes.namespace.name in ("def456-tools","def456-dev","def456-sandbox") and kubernetes.cluster.name in ("silver")`))
+			Expect(fake.scopes[resource.Status.SecureTeamID]).To(Equal(
+				`kubernetes.namespace.name in ("def456-tools","def456-dev","def456-sandbox")`))
+
+			By("using a label outside the allowlist, the spec is rejected")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Team.Scopes.Secure = []opsv1alpha1.ScopeClause{
+				{Label: "host.hostName", Operator: "equals", Values: []string{"node-1"}},
+			}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			reconcileOnce(controllerReconciler)
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Conditions).To(ConsistOf(HaveField("Reason", "InvalidScope")))
+			resource.Spec.Team.Scopes = nil
+
+			By("naming a namespace of another project set, the spec is rejected")
+			resource.Spec.Team.Namespaces.Extra = []string{"other-prod", "def456-missing"}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			reconcileOnce(controllerReconciler)
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Conditions).To(ConsistOf(HaveField("Reason", "InvalidNamespaces")))
+			Expect(resource.Status.Conditions[0].Message).To(ContainSubstring(`"other-prod" is not part of project set def456`))
+			Expect(resource.Status.Conditions[0].Message).To(ContainSubstring(`"def456-missing" does not exist`))
+		})
+	})
+
+	Context("When operators on several clusters manage the same team", func() {
+		const resourceName = "ghi789-team"
+		const namespace = "ghi789-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			err := k8sClient.Create(ctx, ns)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("builds a union of the cluster clauses and only deletes the team with the last cluster", func() {
+			fake := newFakeSysdig()
+			silver := &SysdigTeamGoReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Sysdig: fake, ClusterName: "silver"}
+			gold := &SysdigTeamGoReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Sysdig: fake, ClusterName: "gold"}
+
+			for _, r := range []*SysdigTeamGoReconciler{silver, silver, gold, silver} {
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			teamID := fake.teams["ghi789-team"]
+			Expect(fake.scopes[teamID]).To(Equal(
+				`kubernetes.cluster.name in ("gold","silver") and kubernetes.namespace.name in ("ghi789-tools")`))
+
+			Expect(gold.releaseTeam(ctx, teamID, []string{"ghi789-tools"})).To(Succeed())
+			Expect(fake.teams).To(HaveKey("ghi789-team"))
+			Expect(fake.scopes[teamID]).To(Equal(
+				`kubernetes.cluster.name in ("silver") and kubernetes.namespace.name in ("ghi789-tools")`))
+
+			Expect(silver.releaseTeam(ctx, teamID, []string{"ghi789-tools"})).To(Succeed())
+			Expect(fake.teams).NotTo(HaveKey("ghi789-team"))
+		})
+	})
+
+	Context("When products are configured separately", func() {
+		const resourceName = "jkl012-team"
+		const namespace = "jkl012-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			err := k8sClient.Create(ctx, ns)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						Description: "jkl012 team",
+						Users: []opsv1alpha1.UserSpec{
+							{Name: "jane.doe@gov.bc.ca", Role: "ROLE_TEAM_EDIT"},
+						},
+						Monitor: &opsv1alpha1.ProductSpec{Description: "jkl012 dashboards"},
+						Secure: &opsv1alpha1.ProductSpec{
+							Users: []opsv1alpha1.UserSpec{
+								{Name: "jane.doe@gov.bc.ca", Role: "ROLE_TEAM_READ"},
+								{Name: "sec.analyst@gov.bc.ca", Role: "ROLE_TEAM_EDIT"},
+							},
+						},
+						ServiceAccounts: []opsv1alpha1.ServiceAccountSpec{
+							{Name: "scanner", Product: "secure", Role: "ROLE_TEAM_EDIT"},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("gives each team its own users and deletes a disabled team", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			jane, analyst := fake.users["jane.doe@gov.bc.ca"], fake.users["sec.analyst@gov.bc.ca"]
+			Expect(fake.memberships[resource.Status.MonitorTeamID]).To(Equal(map[int64]string{jane: "ROLE_TEAM_EDIT"}))
+			Expect(fake.memberships[resource.Status.SecureTeamID]).To(Equal(map[int64]string{
+				jane: "ROLE_TEAM_READ", analyst: "ROLE_TEAM_EDIT",
+			}))
+			Expect(fake.descs[resource.Status.MonitorTeamID]).To(Equal("jkl012 dashboards"))
+			Expect(fake.descs[resource.Status.SecureTeamID]).To(Equal("jkl012 team"))
+			Expect(resource.Status.ServiceAccounts).To(HaveLen(1))
+			Expect(fake.accounts).To(HaveKeyWithValue(resource.Status.ServiceAccounts[0].ID, resource.Status.SecureTeamID))
+
+			By("disabling Secure, its service account and team are deleted and Monitor is untouched")
+			disabled := false
+			resource.Spec.Team.Secure.Enabled = &disabled
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.SecureTeamID).To(BeZero())
+			Expect(fake.teams).NotTo(HaveKey("jkl012-team-secure"))
+			Expect(fake.teams).To(HaveKeyWithValue("jkl012-team", resource.Status.MonitorTeamID))
+			Expect(resource.Status.ServiceAccounts).To(BeEmpty())
+			Expect(fake.accounts).To(BeEmpty())
+		})
+
+		It("clamps team settings with the platform policy and reports them", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.Monitor.UI.EntryPoint.Module).To(Equal("Dashboards"))
+			Expect(resource.Status.Monitor.Clamped).To(BeEmpty())
+
+			By("asking for an allowed change and a permission the policy keeps off")
+			resource.Spec.Team.Monitor.UI = &opsv1alpha1.UISpec{
+				EntryPoint: &opsv1alpha1.EntryPointSpec{Module: "Explore"},
+				Theme:      "#FF0000",
+			}
+			resource.Spec.Team.Monitor.Permissions = map[string]bool{"hasAgentCli": false, "hasAwsData": true}
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			status := resource.Status.Monitor
+			Expect(status.UI.EntryPoint.Module).To(Equal("Explore"))
+			Expect(status.UI.Theme).To(Equal("#FF0000"))
+			Expect(status.Permissions).To(HaveKeyWithValue("hasAgentCli", false))
+			Expect(status.Permissions).To(HaveKeyWithValue("hasAwsData", false))
+			Expect(status.Clamped).To(ConsistOf(ContainSubstring("hasAwsData")))
+
+			applied := fake.settings[resource.Status.MonitorTeamID]
+			Expect(applied.UI.Theme).To(Equal("#FF0000"))
+			Expect(applied.Permissions).To(HaveKeyWithValue("hasAgentCli", false))
+			Expect(applied.Permissions).To(HaveKeyWithValue("hasAwsData", false))
+		})
+	})
+
+	Context("When membership is derived from RoleBindings", func() {
+		const resourceName = "mno345-team"
+		const namespace = "mno345-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			err := k8sClient.Create(ctx, ns)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			for _, rb := range []*rbacv1.RoleBinding{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "editors", Namespace: namespace},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "edit"},
+					Subjects: []rbacv1.Subject{
+						{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "Jane.Doe@gov.bc.ca"},
+						{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "jdev@azureidir"},
+						{Kind: rbacv1.ServiceAccountKind, Name: "pipeline", Namespace: namespace},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "viewers", Namespace: namespace},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"},
+					Subjects: []rbacv1.Subject{
+						{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "jane.doe@gov.bc.ca"},
+						{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "auditor@gov.bc.ca"},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: namespace},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"},
+					Subjects: []rbacv1.Subject{
+						{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "lead@gov.bc.ca"},
+					},
+				},
+				{
+					// A namespaced Role named like a ClusterRole is ignored.
+					ObjectMeta: metav1.ObjectMeta{Name: "local-admins", Namespace: namespace},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "admin"},
+					Subjects: []rbacv1.Subject{
+						{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "mallory@gov.bc.ca"},
+					},
+				},
+			} {
+				err := k8sClient.Create(ctx, rb)
+				if err != nil && !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						Users: []opsv1alpha1.UserSpec{
+							{Name: "auditor@gov.bc.ca", Role: "ROLE_TEAM_MANAGER"},
+						},
+						Membership: &opsv1alpha1.MembershipSpec{
+							RoleBindings: &opsv1alpha1.RoleBindingSource{},
+							EmailDomain:  "gov.bc.ca",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("adds bound users with the strongest mapped role", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(fake.users).To(HaveLen(4))
+			Expect(fake.memberships[resource.Status.MonitorTeamID]).To(Equal(map[int64]string{
+				fake.users["jane.doe@gov.bc.ca"]: "ROLE_TEAM_EDIT",
+				fake.users["jdev@gov.bc.ca"]:     "ROLE_TEAM_EDIT",
+				fake.users["lead@gov.bc.ca"]:     "ROLE_TEAM_EDIT",
+				fake.users["auditor@gov.bc.ca"]:  "ROLE_TEAM_MANAGER",
+			}))
+
+			By("mapping RoleBinding changes back to the team")
+			rb := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "viewers", Namespace: namespace}}
+			Expect(controllerReconciler.teamsForRoleBinding(ctx, rb)).To(ConsistOf(
+				reconcile.Request{NamespacedName: typeNamespacedName},
+			))
+		})
+
+		It("removes users whose RoleBinding is deleted", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			lead := fake.users["lead@gov.bc.ca"]
+			Expect(fake.memberships[resource.Status.MonitorTeamID]).To(HaveKey(lead))
+
+			rb := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: namespace}}
+			Expect(k8sClient.Delete(ctx, rb)).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fake.memberships[resource.Status.MonitorTeamID]).NotTo(HaveKey(lead))
+			Expect(fake.memberships[resource.Status.SecureTeamID]).NotTo(HaveKey(lead))
+		})
+	})
+
+	Context("When the team has service accounts", func() {
+		const resourceName = "pqr678-team"
+		const namespace = "pqr678-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			err := k8sClient.Create(ctx, ns)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						ServiceAccounts: []opsv1alpha1.ServiceAccountSpec{
+							{Name: "ci", Product: "monitor", Role: "ROLE_TEAM_EDIT"},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Finalizers = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+		})
+
+		It("writes the token to a Secret, rotates it when due and deletes removed accounts", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			// The first reconcile only adds the finalizer.
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 59*24*time.Hour))
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ServiceAccounts).To(HaveLen(1))
+			first := resource.Status.ServiceAccounts[0]
+			Expect(fake.accounts).To(HaveKeyWithValue(first.ID, resource.Status.MonitorTeamID))
+
+			secret := &corev1.Secret{}
+			secretKey := types.NamespacedName{Name: "sysdig-monitor-ci", Namespace: namespace}
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			Expect(string(secret.Data["token"])).To(Equal(fmt.Sprintf("key-%d", first.ID)))
+
+			By("rotating a token that is due")
+			resource.Status.ServiceAccounts[0].RotateAt = metav1.NewTime(time.Now().Add(-time.Minute))
+			Expect(k8sClient.Status().Update(ctx, resource)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			second := resource.Status.ServiceAccounts[0]
+			Expect(second.ID).NotTo(Equal(first.ID))
+			Expect(fake.accounts).NotTo(HaveKey(first.ID))
+			Expect(k8sClient.Get(ctx, secretKey, secret)).To(Succeed())
+			Expect(string(secret.Data["token"])).To(Equal(fmt.Sprintf("key-%d", second.ID)))
+
+			By("removing the account from the spec")
+			resource.Spec.Team.ServiceAccounts = nil
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.ServiceAccounts).To(BeEmpty())
+			Expect(fake.accounts).To(BeEmpty())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, secretKey, secret))).To(BeTrue())
+		})
+
+		It("does not overwrite a Secret it does not manage", func() {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "sysdig-monitor-ci", Namespace: namespace},
+				StringData: map[string]string{"token": "tenant-owned"},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			defer func() { Expect(k8sClient.Delete(ctx, secret)).To(Succeed()) }()
+
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).To(MatchError(ContainSubstring("refusing to overwrite")))
+
+			Expect(fake.accounts).To(BeEmpty())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "sysdig-monitor-ci", Namespace: namespace}, secret)).To(Succeed())
+			Expect(string(secret.Data["token"])).To(Equal("tenant-owned"))
+		})
+	})
+
+	Context("When the team has notification channels", func() {
+		const resourceName = "stu901-team"
+		const namespace = "stu901-tools"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespace,
+		}
+
+		BeforeEach(func() {
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}
+			err := k8sClient.Create(ctx, ns)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "slack-webhook",
+					Namespace: namespace,
+					Labels:    map[string]string{managedByLabel: managedByValue},
+				},
+				StringData: map[string]string{"url": "https://hooks.slack.com/services/one"},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+			resource := &opsv1alpha1.SysdigTeam{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: namespace,
+				},
+				Spec: opsv1alpha1.SysdigTeamGoSpec{
+					Team: opsv1alpha1.TeamSpec{
+						NotificationChannels: []opsv1alpha1.NotificationChannelSpec{
+							{Name: "on-call", Type: "email", Recipients: []string{"oncall@gov.bc.ca"}},
+							{
+								Name: "alerts", Type: "slack", SlackChannel: "#stu901-alerts",
+								URLSecretRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "slack-webhook"},
+									Key:                  "url",
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &opsv1alpha1.SysdigTeam{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, resource); err == nil {
+				resource.Finalizers = nil
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			}
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack-webhook", Namespace: namespace}}
+			Expect(k8sClient.Delete(ctx, secret)).To(Succeed())
+		})
+
+		It("creates the channels in the Monitor team and keeps them in sync", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			reconcileOnce := func() {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			reconcileOnce()
+			reconcileOnce()
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Status.NotificationChannels).To(HaveLen(2))
+			ids := map[string]int64{}
+			for _, st := range resource.Status.NotificationChannels {
+				ids[st.Name] = st.ID
+				Expect(fake.channels[st.ID].TeamID).To(Equal(resource.Status.MonitorTeamID))
+			}
+			Expect(fake.channels[ids["alerts"]].Config).To(Equal(helpers.NotificationChannelConfig{
+				URL: "https://hooks.slack.com/services/one", Channel: "#stu901-alerts",
+			}))
+
+			By("putting back a channel edited in Sysdig and picking up a new webhook URL")
+			edited := fake.channels[ids["on-call"]]
+			edited.Config.EmailRecipients = []string{"someone@else.com"}
+			fake.channels[ids["on-call"]] = edited
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "slack-webhook", Namespace: namespace}, secret)).To(Succeed())
+			secret.Data["url"] = []byte("https://hooks.slack.com/services/two")
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+			reconcileOnce()
+
+			Expect(fake.channels[ids["on-call"]].Config.EmailRecipients).To(Equal([]string{"oncall@gov.bc.ca"}))
+			Expect(fake.channels[ids["alerts"]].Config.URL).To(Equal("https://hooks.slack.com/services/two"))
+
+			By("deleting a channel removed from the spec")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			resource.Spec.Team.NotificationChannels = resource.Spec.Team.NotificationChannels[1:]
+			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+			reconcileOnce()
+
+			Expect(fake.channels).NotTo(HaveKey(ids["on-call"]))
+			Expect(fake.channels).To(HaveKey(ids["alerts"]))
+			Expect(controllerReconciler.teamsForSecret(ctx, secret)).To(ConsistOf(
+				reconcile.Request{NamespacedName: typeNamespacedName},
+			))
+		})
+
+		It("keeps the finalizer until the channels are deleted", func() {
+			fake := newFakeSysdig()
+			controllerReconciler := &SysdigTeamGoReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Sysdig: fake,
+			}
+			for i := 0; i < 2; i++ {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(fake.channels).To(HaveLen(2))
+
+			resource := &opsv1alpha1.SysdigTeam{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+
+			fake.deleteErr = &helpers.SysdigAPIError{Operation: "DeleteNotificationChannel", StatusCode: 503}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).To(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+			Expect(resource.Finalizers).NotTo(BeEmpty())
+			Expect(fake.teams).To(HaveKey(resourceName))
+
+			fake.deleteErr = nil
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fake.channels).To(BeEmpty())
+			Expect(fake.teams).NotTo(HaveKey(resourceName))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, resource))).To(BeTrue())
+		})
+	})
 })
@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	api "github.com/bcgov/platform-services-sysdig/sysdig-operator/api/v1alpha1"
+	helpers "github.com/bcgov/platform-services-sysdig/sysdig-operator/internal/helper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	sysdigAlertFinalizer     = "ops.gov.bc.ca/sysdig-alert"
+	sysdigDashboardFinalizer = "ops.gov.bc.ca/sysdig-dashboard"
+)
+
+// teamObject is a SysdigAlert or SysdigDashboard with its status conditions.
+type teamObject struct {
+	client.Object
+	conditions *[]api.Condition
+}
+
+// teamResource is what the SysdigAlert and SysdigDashboard reconcilers
+// share. Both sync an object in a -tools namespace to a Sysdig object in
+// the Monitor team of that namespace, and report it in a Synced condition.
+type teamResource struct {
+	client.Client
+	sysdig helpers.SysdigAPI
+
+	// kind names the Sysdig object in messages, e.g. "alert".
+	kind      string
+	finalizer string
+}
+
+// owningTeam returns the SysdigTeam of a -tools namespace, or nil.
+func owningTeam(ctx context.Context, c client.Client, namespace string) (*api.SysdigTeam, error) {
+	var teams api.SysdigTeamList
+	if err := c.List(ctx, &teams, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("list SysdigTeams in %s: %w", namespace, err)
+	}
+	for i := range teams.Items {
+		if teams.Items[i].DeletionTimestamp.IsZero() {
+			return &teams.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// finalize deletes the Sysdig object id with remove and then drops the
+// finalizer. Without a Sysdig client the finalizer is kept, so the Sysdig
+// object is not left behind.
+func (t *teamResource) finalize(ctx context.Context, obj teamObject, id int64, remove func() error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if id != 0 {
+		if t.sysdig == nil {
+			msg := fmt.Sprintf("Cannot delete the Sysdig %s: SYSDIG_REGION or SYSDIG_API_ENDPOINT, and SYSDIG_TOKEN, are not set", t.kind)
+			if err := t.setSynced(ctx, obj, metav1.ConditionFalse, "MissingEnvVars", msg); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{}, fmt.Errorf("%s", msg)
+		}
+		if err := remove(); err != nil && !helpers.IsNotFound(err) {
+			logger.Error(err, "Failed to delete Sysdig "+t.kind, "ID", id)
+			return ctrl.Result{}, err
+		}
+		logger.Info("Deleted Sysdig "+t.kind, "ID", id)
+	}
+	controllerutil.RemoveFinalizer(obj.Object, sysdigTeamFinalizer)
+	controllerutil.RemoveFinalizer(obj.Object, t.finalizer)
+	return ctrl.Result{}, t.Update(ctx, obj.Object)
+}
+
+// prepare adds the finalizer and returns the SysdigTeam whose Monitor team
+// obj is synced to. A nil team ends the reconcile with the returned result.
+func (t *teamResource) prepare(ctx context.Context, obj teamObject) (*api.SysdigTeam, ctrl.Result, error) {
+	// Objects created before they had a finalizer of their own carry the
+	// SysdigTeam one.
+	replaced := controllerutil.RemoveFinalizer(obj.Object, sysdigTeamFinalizer)
+	if controllerutil.AddFinalizer(obj.Object, t.finalizer) || replaced {
+		if err := t.Update(ctx, obj.Object); err != nil {
+			return nil, ctrl.Result{}, err
+		}
+	}
+
+	if t.sysdig == nil {
+		msg := "Environment variables SYSDIG_REGION or SYSDIG_API_ENDPOINT, and SYSDIG_TOKEN, are not set"
+		if err := t.setSynced(ctx, obj, metav1.ConditionFalse, "MissingEnvVars", msg); err != nil {
+			return nil, ctrl.Result{}, err
+		}
+		return nil, ctrl.Result{}, fmt.Errorf("%s", msg)
+	}
+
+	team, err := owningTeam(ctx, t.Client, obj.GetNamespace())
+	if err != nil {
+		return nil, ctrl.Result{}, err
+	}
+	if team == nil || team.Status.MonitorTeamID == 0 {
+		// The SysdigTeam watch brings us back once the team is ready.
+		return nil, ctrl.Result{}, t.setSynced(ctx, obj, metav1.ConditionFalse, "TeamNotReady",
+			fmt.Sprintf("No SysdigTeam with a Monitor team in namespace %s", obj.GetNamespace()))
+	}
+	return team, ctrl.Result{}, nil
+}
+
+// setSynced records the Synced condition together with the rest of the
+// status. The error has to reach the caller: the status holds the ID of a
+// Sysdig object that was just created, and losing it would orphan the object.
+func (t *teamResource) setSynced(
+	ctx context.Context,
+	obj teamObject,
+	status metav1.ConditionStatus,
+	reason, message string,
+) error {
+	*obj.conditions = []api.Condition{
+		{Type: "Synced", Status: status, Reason: reason, Message: message},
+	}
+	if err := t.Status().Update(ctx, obj.Object); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to update status", "reason", reason)
+		return fmt.Errorf("update status: %w", err)
+	}
+	return nil
+}
+
+// fail records a failed Sysdig call and decides how to requeue, like
+// SysdigTeamGoReconciler.failReconcile.
+func (t *teamResource) fail(ctx context.Context, obj teamObject, message string, err error) (ctrl.Result, error) {
+	reason, result, retErr := classifySysdigError(err, "SyncFailed")
+	log.FromContext(ctx).Error(err, message, "reason", reason)
+	if err := t.setSynced(ctx, obj, metav1.ConditionFalse, reason, message+": "+err.Error()); err != nil && retErr == nil {
+		return ctrl.Result{}, err
+	}
+	return result, retErr
+}
@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// Alert types accepted by /api/v2/alerts.
+const (
+	AlertTypePrometheus = "PROMETHEUS"
+	AlertTypeMetric     = "MANUAL"
+)
+
+// Alert is a Monitor alert from /api/v2/alerts.
+type Alert struct {
+	ID          int64  `json:"id,omitempty"`
+	Version     int64  `json:"version,omitempty"`
+	TeamID      int64  `json:"teamId,omitempty"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Severity is 0 (high) to 7 (info).
+	Severity int  `json:"severity"`
+	Enabled  bool `json:"enabled"`
+	// Timespan is how long the condition must hold, in microseconds.
+	Timespan  int64  `json:"timespan"`
+	Condition string `json:"condition"`
+	// Filter is a scope expression, e.g. from BuildFilterExpression.
+	Filter                 string  `json:"filter,omitempty"`
+	NotificationChannelIDs []int64 `json:"notificationChannelIds,omitempty"`
+}
+
+// alertEnvelope wraps an Alert the way /api/v2/alerts sends and expects it.
+type alertEnvelope struct {
+	Alert Alert `json:"alert"`
+}
+
+// CreateAlert creates an alert and returns it with its ID and version.
+func (c *SysdigClient) CreateAlert(ctx context.Context, alert Alert) (*Alert, error) {
+	alert.ID, alert.Version = 0, 0
+	url := fmt.Sprintf("%s/api/v2/alerts", c.endpoints.Monitor)
+	return c.sendAlert(ctx, "CreateAlert", "POST", url, &alert, http.StatusCreated, http.StatusOK)
+}
+
+// GetAlert fetches an alert by ID.
+func (c *SysdigClient) GetAlert(ctx context.Context, id int64) (*Alert, error) {
+	url := fmt.Sprintf("%s/api/v2/alerts/%d", c.endpoints.Monitor, id)
+	return c.sendAlert(ctx, "GetAlert", "GET", url, nil, http.StatusOK)
+}
+
+// UpdateAlert replaces an alert. alert.Version must be the version last
+// read, or Sysdig rejects the update with a conflict.
+func (c *SysdigClient) UpdateAlert(ctx context.Context, alert *Alert) (*Alert, error) {
+	url := fmt.Sprintf("%s/api/v2/alerts/%d", c.endpoints.Monitor, alert.ID)
+	return c.sendAlert(ctx, "UpdateAlert", "PUT", url, alert, http.StatusOK)
+}
+
+// DeleteAlert deletes an alert by ID.
+func (c *SysdigClient) DeleteAlert(ctx context.Context, id int64) error {
+	ctx, cancel := c.withTimeout(ctx, "DeleteAlert")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/api/v2/alerts/%d", c.endpoints.Monitor, id)
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do("DeleteAlert", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("DeleteAlert", resp, body)
+	}
+	return nil
+}
+
+// sendAlert sends alert, if any, wrapped in its envelope and decodes the
+// alert in the response.
+func (c *SysdigClient) sendAlert(
+	ctx context.Context,
+	op, method, url string,
+	alert *Alert,
+	okStatus ...int,
+) (*Alert, error) {
+	ctx, cancel := c.withTimeout(ctx, op)
+	defer cancel()
+
+	var payload interface{}
+	if alert != nil {
+		payload = alertEnvelope{Alert: *alert}
+		ctx = inTeam(ctx, alert.TeamID)
+	}
+	req, err := c.newRequest(ctx, method, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(op, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s response body: %w", op, err)
+	}
+	if !slices.Contains(okStatus, resp.StatusCode) {
+		return nil, newAPIError(op, resp, body)
+	}
+
+	var envelope alertEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("parsing %s response JSON: %w", op, err)
+	}
+	return &envelope.Alert, nil
+}
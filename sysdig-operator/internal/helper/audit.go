@@ -0,0 +1,125 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// maxAuditBodyBytes caps how much of a body is written to the log.
+const maxAuditBodyBytes = 4096
+
+// AuditConfig controls the per-request log written for every Sysdig API call.
+type AuditConfig struct {
+	// Verbosity is the logr V-level of the request lines. 0 logs them at
+	// info level; the default of 1 only shows them with debug logging.
+	Verbosity int
+	// LogBodies adds the redacted request and response bodies. Bodies are
+	// only written when the logger is also enabled at debug level.
+	LogBodies bool
+}
+
+// DefaultAuditConfig returns the audit settings used by NewSysdigClient.
+func DefaultAuditConfig() AuditConfig {
+	return AuditConfig{Verbosity: 1}
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/=]+`)
+	secretPattern = regexp.MustCompile(`(?i)"(token|apiKey|accessKey|password|secret|url)"\s*:\s*"[^"]*"`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@([A-Za-z0-9\-]+\.)+[A-Za-z]{2,}`)
+)
+
+// redact masks bearer tokens, secret JSON fields such as webhook URLs, and the local part of email
+// addresses, e.g. "***@gov.bc.ca".
+func redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer <redacted>")
+	s = secretPattern.ReplaceAllString(s, `"$1":"<redacted>"`)
+	return emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		return "***" + email[strings.LastIndex(email, "@"):]
+	})
+}
+
+// auditPath returns the request path and decoded query with user data redacted.
+func auditPath(u *url.URL) string {
+	path := u.Path
+	if u.RawQuery != "" {
+		query, err := url.QueryUnescape(u.RawQuery)
+		if err != nil {
+			query = u.RawQuery
+		}
+		path += "?" + query
+	}
+	return redact(path)
+}
+
+// auditBody returns a redacted, truncated copy of body for the log.
+func auditBody(body []byte) string {
+	truncated := len(body) > maxAuditBodyBytes
+	if truncated {
+		body = body[:maxAuditBodyBytes]
+	}
+	s := redact(string(body))
+	if truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
+
+// audit logs one HTTP attempt. The response body is buffered and put back
+// when it is logged, so callers can still read it.
+func (c *SysdigClient) audit(
+	ctx context.Context,
+	op string,
+	attempt int,
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	latency time.Duration,
+) {
+	logger := log.FromContext(ctx).WithName("sysdig-api").V(c.auditing.Verbosity)
+	if !logger.Enabled() {
+		return
+	}
+
+	kv := []interface{}{
+		"operation", op,
+		"method", req.Method,
+		"path", auditPath(req.URL),
+		"attempt", attempt,
+		"latency", latency.String(),
+	}
+	if err != nil {
+		kv = append(kv, "error", redact(err.Error()))
+	}
+	if resp != nil {
+		kv = append(kv, "status", resp.StatusCode, "requestID", resp.Header.Get("X-Request-Id"))
+	}
+
+	if c.auditing.LogBodies && log.FromContext(ctx).V(1).Enabled() {
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				payload, _ := io.ReadAll(body)
+				body.Close()
+				kv = append(kv, "requestBody", auditBody(payload))
+			}
+		}
+		if resp != nil && resp.Body != nil {
+			payload, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(payload))
+			if readErr == nil {
+				kv = append(kv, "responseBody", auditBody(payload))
+			}
+		}
+	}
+
+	logger.Info("Sysdig API request", kv...)
+}
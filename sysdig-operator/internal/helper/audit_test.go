@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var _ = Describe("Audit log", func() {
+	var (
+		server *httptest.Server
+		client *SysdigClient
+		lines  []string
+		logCtx context.Context
+	)
+
+	BeforeEach(func() {
+		lines = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-Id", "req-42")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":9,"email":"jane.doe@gov.bc.ca","token":"s3cr3t"}`))
+		}))
+		client = NewSysdigClient(server.URL, "", "super-secret-token")
+		logger := funcr.New(func(prefix, args string) {
+			lines = append(lines, args)
+		}, funcr.Options{Verbosity: 1})
+		logCtx = log.IntoContext(ctx, logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("logs method, path, status and request ID without user data", func() {
+		_, err := client.FindUserByEmail(logCtx, "jane.doe@gov.bc.ca")
+		Expect(err).To(HaveOccurred()) // 201 is not a valid listing response
+
+		Expect(lines).NotTo(BeEmpty())
+		line := lines[0]
+		Expect(line).To(ContainSubstring(`"method"="GET"`))
+		Expect(line).To(ContainSubstring(`"path"="/platform/v1/users?`))
+		Expect(line).To(ContainSubstring(`***@gov.bc.ca`))
+		Expect(line).To(ContainSubstring(`"status"=201`))
+		Expect(line).To(ContainSubstring(`"requestID"="req-42"`))
+		Expect(line).NotTo(ContainSubstring("jane.doe"))
+		Expect(line).NotTo(ContainSubstring("responseBody"))
+	})
+
+	It("logs redacted bodies only when asked to", func() {
+		client.WithAuditLog(AuditConfig{Verbosity: 1, LogBodies: true})
+
+		id, err := client.CreateUser(logCtx, "jane.doe@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(int64(9)))
+
+		all := strings.Join(lines, "\n")
+		Expect(all).To(ContainSubstring("requestBody"))
+		Expect(all).To(ContainSubstring("responseBody"))
+		Expect(all).NotTo(ContainSubstring("jane.doe"))
+		Expect(all).NotTo(ContainSubstring("s3cr3t"))
+		Expect(all).NotTo(ContainSubstring("super-secret-token"))
+	})
+
+	It("stays quiet above the configured verbosity", func() {
+		client.WithAuditLog(AuditConfig{Verbosity: 2, LogBodies: true})
+
+		_, err := client.CreateUser(logCtx, "jane.doe@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lines).To(BeEmpty())
+	})
+
+	It("redacts bearer tokens and email addresses", func() {
+		Expect(redact(`Authorization: Bearer abc.DEF-123 for Jane.Doe+ops@gov.bc.ca`)).
+			To(Equal(`Authorization: Bearer <redacted> for ***@gov.bc.ca`))
+	})
+})
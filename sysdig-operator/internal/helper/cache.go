@@ -0,0 +1,262 @@
+package helpers
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// CacheConfig controls the shared user/team cache.
+type CacheConfig struct {
+	// TTL is how long a bulk snapshot is trusted. Lookups against an older
+	// snapshot go to the API.
+	TTL time.Duration
+	// RefreshInterval is how often Start reloads every user and team.
+	RefreshInterval time.Duration
+	// MissTTL is how long a lookup that found nothing is trusted, so a
+	// missing user or team is not looked up on every reconcile. 0 disables
+	// it.
+	MissTTL time.Duration
+}
+
+// CachingClient wraps a SysdigClient with an in-memory cache of the tenant's
+// users and teams. The cache is filled by a periodic bulk refresh, so most
+// reconciles resolve names and emails without any read calls. Lookups that
+// miss fall through to the API, and our own team and user writes invalidate
+// the affected entries. Lookups that find nothing are remembered for MissTTL.
+//
+// CachingClient is a manager.Runnable: add it to the manager so the refresh
+// loop runs for the lifetime of the operator.
+type CachingClient struct {
+	*SysdigClient
+
+	cfg CacheConfig
+
+	mu          sync.RWMutex
+	teams       map[string][]SysdigTeam
+	users       map[string][]SysdigUser
+	teamsLoaded time.Time
+	usersLoaded time.Time
+	// teamMisses and userMisses record when a lookup found nothing.
+	teamMisses map[string]time.Time
+	userMisses map[string]time.Time
+}
+
+// CachingClient must satisfy SysdigAPI.
+var _ SysdigAPI = &CachingClient{}
+
+// NewCachingClient returns a caching wrapper around c.
+func NewCachingClient(c *SysdigClient, cfg CacheConfig) *CachingClient {
+	return &CachingClient{
+		SysdigClient: c,
+		cfg:          cfg,
+		teams:        map[string][]SysdigTeam{},
+		users:        map[string][]SysdigUser{},
+		teamMisses:   map[string]time.Time{},
+		userMisses:   map[string]time.Time{},
+	}
+}
+
+// cacheKey normalises names and emails the same way the Find* lookups match them.
+func cacheKey(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// Start runs the periodic bulk refresh until ctx is cancelled.
+func (c *CachingClient) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("sysdig-cache")
+	interval := c.cfg.RefreshInterval
+	if interval <= 0 {
+		interval = c.cfg.TTL / 2
+	}
+	if interval <= 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Refresh(ctx); err != nil {
+			logger.Error(err, "Failed to refresh Sysdig user and team cache")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh reloads every team and user from the API. On error the previous
+// snapshot is kept.
+func (c *CachingClient) Refresh(ctx context.Context) error {
+	teams := map[string][]SysdigTeam{}
+	for t, err := range c.IterTeams(ctx, ListOptions{}) {
+		if err != nil {
+			cacheRefreshesTotal.WithLabelValues("team", "error").Inc()
+			return err
+		}
+		teams[cacheKey(t.Name)] = append(teams[cacheKey(t.Name)], t)
+	}
+	c.mu.Lock()
+	c.teams, c.teamsLoaded = teams, time.Now()
+	c.mu.Unlock()
+	cacheRefreshesTotal.WithLabelValues("team", "success").Inc()
+
+	users := map[string][]SysdigUser{}
+	for u, err := range c.IterUsers(ctx, ListOptions{}) {
+		if err != nil {
+			cacheRefreshesTotal.WithLabelValues("user", "error").Inc()
+			return err
+		}
+		users[cacheKey(u.Email)] = append(users[cacheKey(u.Email)], u)
+	}
+	c.mu.Lock()
+	c.users, c.usersLoaded = users, time.Now()
+	c.mu.Unlock()
+	cacheRefreshesTotal.WithLabelValues("user", "success").Inc()
+	return nil
+}
+
+// fresh reports whether a snapshot loaded at the given time is within the TTL.
+func (c *CachingClient) fresh(loaded time.Time) bool {
+	return !loaded.IsZero() && time.Since(loaded) < c.cfg.TTL
+}
+
+// freshMiss reports whether a lookup that found nothing at the given time is
+// within the MissTTL.
+func (c *CachingClient) freshMiss(missed time.Time) bool {
+	return !missed.IsZero() && time.Since(missed) < c.cfg.MissTTL
+}
+
+// FindTeamByName serves the lookup from the cache when possible.
+func (c *CachingClient) FindTeamByName(ctx context.Context, name string) (*SysdigTeam, error) {
+	key := cacheKey(name)
+	c.mu.RLock()
+	cached, ok := c.teams[key]
+	fresh := c.fresh(c.teamsLoaded)
+	missing := c.freshMiss(c.teamMisses[key])
+	c.mu.RUnlock()
+
+	if fresh && ok && len(cached) > 0 {
+		cacheRequestsTotal.WithLabelValues("team", "hit").Inc()
+		if len(cached) > 1 {
+			return nil, &AmbiguousMatchError{Kind: "team", Key: name, IDs: teamIDs(cached)}
+		}
+		team := cached[0]
+		return &team, nil
+	}
+	if missing {
+		cacheRequestsTotal.WithLabelValues("team", "hit").Inc()
+		return nil, nil
+	}
+
+	cacheRequestsTotal.WithLabelValues("team", "miss").Inc()
+	team, err := c.SysdigClient.FindTeamByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if team == nil {
+		c.teamMisses[key] = time.Now()
+	} else {
+		c.teams[key] = []SysdigTeam{*team}
+	}
+	c.mu.Unlock()
+	return team, nil
+}
+
+// FindUserByEmail serves the lookup from the cache when possible.
+func (c *CachingClient) FindUserByEmail(ctx context.Context, email string) (*SysdigUser, error) {
+	key := cacheKey(email)
+	c.mu.RLock()
+	cached, ok := c.users[key]
+	fresh := c.fresh(c.usersLoaded)
+	missing := c.freshMiss(c.userMisses[key])
+	c.mu.RUnlock()
+
+	if fresh && ok && len(cached) > 0 {
+		cacheRequestsTotal.WithLabelValues("user", "hit").Inc()
+		if len(cached) > 1 {
+			return nil, &AmbiguousMatchError{Kind: "user", Key: email, IDs: userIDs(cached)}
+		}
+		user := cached[0]
+		return &user, nil
+	}
+	if missing {
+		cacheRequestsTotal.WithLabelValues("user", "hit").Inc()
+		return nil, nil
+	}
+
+	cacheRequestsTotal.WithLabelValues("user", "miss").Inc()
+	user, err := c.SysdigClient.FindUserByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if user == nil {
+		c.userMisses[key] = time.Now()
+	} else {
+		c.users[key] = []SysdigUser{*user}
+	}
+	c.mu.Unlock()
+	return user, nil
+}
+
+// CreateTeam creates the team and drops any cached entry or miss for its name.
+func (c *CachingClient) CreateTeam(
+	ctx context.Context,
+	name, description, product string,
+	scopes []Scope,
+	settings TeamSettings,
+) (int64, error) {
+	c.mu.Lock()
+	delete(c.teams, cacheKey(name))
+	delete(c.teamMisses, cacheKey(name))
+	c.mu.Unlock()
+	return c.SysdigClient.CreateTeam(ctx, name, description, product, scopes, settings)
+}
+
+// DeleteTeam deletes the team and drops it from the cache.
+func (c *CachingClient) DeleteTeam(ctx context.Context, teamID int64) error {
+	err := c.SysdigClient.DeleteTeam(ctx, teamID)
+	c.mu.Lock()
+	for key, teams := range c.teams {
+		for _, t := range teams {
+			if t.ID == teamID {
+				delete(c.teams, key)
+				break
+			}
+		}
+	}
+	c.mu.Unlock()
+	return err
+}
+
+// CreateUser creates the user and drops any cached entry or miss for its email.
+func (c *CachingClient) CreateUser(ctx context.Context, email, role string) (int64, error) {
+	c.mu.Lock()
+	delete(c.users, cacheKey(email))
+	delete(c.userMisses, cacheKey(email))
+	c.mu.Unlock()
+	return c.SysdigClient.CreateUser(ctx, email, role)
+}
+
+func teamIDs(teams []SysdigTeam) []int64 {
+	ids := make([]int64, 0, len(teams))
+	for _, t := range teams {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func userIDs(users []SysdigUser) []int64 {
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
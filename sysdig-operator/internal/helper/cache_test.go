@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("CachingClient", func() {
+	var (
+		server    *httptest.Server
+		cache     *CachingClient
+		userReads int
+	)
+
+	BeforeEach(func() {
+		userReads = 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/platform/v1/users", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(`{"id": 3, "email": "new.user@gov.bc.ca"}`))
+				return
+			}
+			userReads++
+			_ = json.NewEncoder(w).Encode(listResponse[SysdigUser]{Data: []SysdigUser{
+				{ID: 1, Email: "jane.doe@gov.bc.ca"},
+				{ID: 2, Email: "john.doe@gov.bc.ca"},
+			}})
+		})
+		mux.HandleFunc("/platform/v1/teams", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(listResponse[SysdigTeam]{Data: []SysdigTeam{{ID: 7, Name: "abc123-team"}}})
+		})
+		server = httptest.NewServer(mux)
+		cache = NewCachingClient(NewSysdigClient(server.URL, "", "token"), CacheConfig{TTL: time.Minute, MissTTL: time.Minute})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("serves lookups from the bulk snapshot", func() {
+		Expect(cache.Refresh(ctx)).To(Succeed())
+		Expect(userReads).To(Equal(1))
+		hits := testutil.ToFloat64(cacheRequestsTotal.WithLabelValues("user", "hit"))
+
+		user, err := cache.FindUserByEmail(ctx, "John.Doe@gov.bc.ca")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user.ID).To(Equal(int64(2)))
+		team, err := cache.FindTeamByName(ctx, "abc123-team")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(team.ID).To(Equal(int64(7)))
+
+		Expect(userReads).To(Equal(1))
+		Expect(testutil.ToFloat64(cacheRequestsTotal.WithLabelValues("user", "hit"))).To(Equal(hits + 1))
+	})
+
+	It("falls through to the API when the snapshot is stale", func() {
+		user, err := cache.FindUserByEmail(ctx, "jane.doe@gov.bc.ca")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user.ID).To(Equal(int64(1)))
+		Expect(userReads).To(Equal(1))
+	})
+
+	It("invalidates an email on CreateUser", func() {
+		Expect(cache.Refresh(ctx)).To(Succeed())
+		_, err := cache.CreateUser(ctx, "jane.doe@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = cache.FindUserByEmail(ctx, "jane.doe@gov.bc.ca")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userReads).To(Equal(2))
+	})
+
+	It("remembers a missing email until CreateUser", func() {
+		for i := 0; i < 2; i++ {
+			user, err := cache.FindUserByEmail(ctx, "new.user@gov.bc.ca")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user).To(BeNil())
+		}
+		Expect(userReads).To(Equal(1))
+
+		_, err := cache.CreateUser(ctx, "new.user@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cache.FindUserByEmail(ctx, "new.user@gov.bc.ca")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userReads).To(Equal(2))
+	})
+})
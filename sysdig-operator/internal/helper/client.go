@@ -0,0 +1,277 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDashboardAPIEndpoint is used when no dashboard endpoint is configured.
+// It is the Monitor endpoint of DefaultRegion.
+const DefaultDashboardAPIEndpoint = "https://app.sysdigcloud.com"
+
+// DefaultOperationTimeout bounds an operation that has no timeout of its own.
+const DefaultOperationTimeout = 15 * time.Second
+
+// SysdigAPI is the set of Sysdig operations used by the operator.
+// The reconciler depends on this interface so it can be tested with a fake.
+type SysdigAPI interface {
+	FindTeamByName(ctx context.Context, name string) (*SysdigTeam, error)
+	GetTeam(ctx context.Context, teamID int64) (*TeamDetails, error)
+	CreateTeam(ctx context.Context, name, description, product string, scopes []Scope, settings TeamSettings) (int64, error)
+	UpdateTeam(ctx context.Context, team *TeamDetails) error
+	DeleteTeam(ctx context.Context, teamID int64) error
+
+	FindUserByEmail(ctx context.Context, email string) (*SysdigUser, error)
+	CreateUser(ctx context.Context, email, role string) (int64, error)
+
+	FetchTeamMemberships(ctx context.Context, teamID int64) ([]TeamMembership, error)
+	SaveMembership(ctx context.Context, teamID, userID int64, role string) (string, error)
+	DeleteMembership(ctx context.Context, teamID, userID int64) error
+
+	CreateServiceAccount(ctx context.Context, teamID int64, name, role string, expiresAt time.Time) (*ServiceAccount, error)
+	DeleteServiceAccount(ctx context.Context, teamID, accountID int64) error
+
+	CreateNotificationChannel(ctx context.Context, channel NotificationChannel) (int64, error)
+	GetNotificationChannel(ctx context.Context, id int64) (*NotificationChannel, error)
+	UpdateNotificationChannel(ctx context.Context, channel *NotificationChannel) error
+	DeleteNotificationChannel(ctx context.Context, id int64) error
+
+	CreateAlert(ctx context.Context, alert Alert) (*Alert, error)
+	GetAlert(ctx context.Context, id int64) (*Alert, error)
+	UpdateAlert(ctx context.Context, alert *Alert) (*Alert, error)
+	DeleteAlert(ctx context.Context, id int64) error
+
+	CreateDashboard(ctx context.Context, t DashboardTemplate, data DashboardContext) (*Dashboard, error)
+	CreateTeamDashboard(ctx context.Context, d *Dashboard) (*Dashboard, error)
+	GetDashboard(ctx context.Context, id int64) (*Dashboard, error)
+	ListDashboards(ctx context.Context, teamID int64) ([]Dashboard, error)
+	UpdateDashboard(ctx context.Context, d *Dashboard) (*Dashboard, error)
+	DeleteDashboard(ctx context.Context, id int64) error
+}
+
+// SysdigClient talks to the Sysdig platform and dashboard APIs.
+// It holds the base URLs, the API token and a shared http.Client,
+// so every call reuses the same transport and connection pool.
+type SysdigClient struct {
+	endpoints  Endpoints
+	token      string
+	httpClient *http.Client
+	retry      RetryConfig
+	limiter    *rateLimiter
+	timeouts   TimeoutConfig
+	auditing   AuditConfig
+	teamTokens *teamTokens
+}
+
+// TimeoutConfig bounds how long a single operation may take, keyed by
+// operation name. For paginated listings the timeout applies to each page.
+type TimeoutConfig struct {
+	Default    time.Duration
+	Operations map[string]time.Duration
+}
+
+// SysdigClient must satisfy SysdigAPI.
+var _ SysdigAPI = &SysdigClient{}
+
+// NewSysdigClient returns a client for the given platform endpoint and token.
+// dashboardAPIEndpoint falls back to DefaultDashboardAPIEndpoint when empty,
+// and the Secure API is assumed to live next to the dashboards.
+func NewSysdigClient(apiEndpoint, dashboardAPIEndpoint, token string) *SysdigClient {
+	if dashboardAPIEndpoint == "" {
+		dashboardAPIEndpoint = DefaultDashboardAPIEndpoint
+	}
+	return NewSysdigClientForEndpoints(Endpoints{
+		Monitor:  dashboardAPIEndpoint,
+		Secure:   dashboardAPIEndpoint,
+		Platform: apiEndpoint,
+	}, token)
+}
+
+// NewSysdigClientForEndpoints returns a client for endpoints resolved by
+// ResolveEndpoints.
+func NewSysdigClientForEndpoints(endpoints Endpoints, token string) *SysdigClient {
+	return &SysdigClient{
+		endpoints:  endpoints,
+		token:      token,
+		httpClient: &http.Client{},
+		retry:      DefaultRetryConfig(),
+		limiter:    newRateLimiter(DefaultRateLimitConfig()),
+		timeouts:   TimeoutConfig{Default: DefaultOperationTimeout},
+		auditing:   DefaultAuditConfig(),
+	}
+}
+
+// Endpoints returns the base URLs the client talks to.
+func (c *SysdigClient) Endpoints() Endpoints {
+	return c.endpoints
+}
+
+// WithHTTPClient replaces the underlying http.Client, e.g. for tests.
+func (c *SysdigClient) WithHTTPClient(hc *http.Client) *SysdigClient {
+	c.httpClient = hc
+	return c
+}
+
+// WithRetryConfig replaces the retry policies used for every operation.
+func (c *SysdigClient) WithRetryConfig(rc RetryConfig) *SysdigClient {
+	c.retry = rc
+	return c
+}
+
+// WithRateLimit replaces the client-side rate limits. The limiter is shared by
+// every caller of this client, so all reconciles draw from the same budget.
+func (c *SysdigClient) WithRateLimit(cfg RateLimitConfig) *SysdigClient {
+	c.limiter = newRateLimiter(cfg)
+	return c
+}
+
+// WithTimeouts replaces the per-operation timeouts.
+func (c *SysdigClient) WithTimeouts(tc TimeoutConfig) *SysdigClient {
+	if tc.Default <= 0 {
+		tc.Default = DefaultOperationTimeout
+	}
+	c.timeouts = tc
+	return c
+}
+
+// WithAuditLog replaces the request logging settings.
+func (c *SysdigClient) WithAuditLog(cfg AuditConfig) *SysdigClient {
+	c.auditing = cfg
+	return c
+}
+
+// ParseOperationTimeouts parses a comma-separated list of operation=duration
+// pairs, e.g. "CreateDashboard=30s,FetchUsers=20s".
+func ParseOperationTimeouts(s string) (map[string]time.Duration, error) {
+	out := map[string]time.Duration{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		op, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid operation timeout %q, want operation=duration", pair)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for %s: %w", op, err)
+		}
+		out[strings.TrimSpace(op)] = d
+	}
+	return out, nil
+}
+
+// withTimeout bounds ctx by the timeout configured for op. Cancellation of
+// the parent context (manager shutdown, lost leadership) still applies.
+func (c *SysdigClient) withTimeout(ctx context.Context, op string) (context.Context, context.CancelFunc) {
+	timeout := c.timeouts.Default
+	if d, ok := c.timeouts.Operations[op]; ok && d > 0 {
+		timeout = d
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// newRequest builds an authenticated request. A non-nil body is sent as JSON.
+// Requests in a team set by WithTeam carry that team's token.
+func (c *SysdigClient) newRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	var buf *bytes.Buffer
+	if body != nil {
+		switch b := body.(type) {
+		case []byte:
+			buf = bytes.NewBuffer(b)
+		default:
+			payload, err := json.Marshal(body)
+			if err != nil {
+				return nil, fmt.Errorf("encoding request body: %w", err)
+			}
+			buf = bytes.NewBuffer(payload)
+		}
+	}
+
+	var req *http.Request
+	var err error
+	if buf != nil {
+		req, err = http.NewRequestWithContext(ctx, method, url, buf)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.requestToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// do executes the request with the shared http.Client. Every attempt first
+// takes a token from the shared rate limiter and is written to the audit log. Throttled and failed attempts
+// are retried according to the operation's retry policy; the last response
+// or error is returned to the caller. Backoff waits are abandoned as soon as
+// the request context is done.
+func (c *SysdigClient) do(op string, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	policy := c.retry.policyFor(op)
+	attempts := max(policy.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx, op, req.Method); err != nil {
+			return nil, fmt.Errorf("waiting for %s rate limit: %w", op, err)
+		}
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		c.audit(ctx, op, attempt, req, resp, err, time.Since(start))
+		if err != nil {
+			apiRequestsTotal.WithLabelValues(op, "error").Inc()
+		} else {
+			apiRequestsTotal.WithLabelValues(op, strconv.Itoa(resp.StatusCode)).Inc()
+		}
+
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			// A revoked team token is fetched again on the next request.
+			c.forgetTeamToken(teamFromContext(ctx))
+		}
+
+		reason := retryReason(policy, req.Method, resp, err)
+		if reason == "" || attempt >= attempts || ctx.Err() != nil {
+			return resp, err
+		}
+		// The body has to be replayable for another attempt.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		delay := backoff(policy, attempt)
+		if ra, ok := retryAfter(resp); ok {
+			delay = min(ra, policy.MaxDelay)
+		}
+		drain(resp)
+		apiRetriesTotal.WithLabelValues(op, reason).Inc()
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%s cancelled while waiting to retry: %w", op, ctx.Err())
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewinding %s request body: %w", op, err)
+			}
+			req.Body = body
+		}
+	}
+}
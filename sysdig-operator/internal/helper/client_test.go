@@ -0,0 +1,267 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SysdigClient", func() {
+	var (
+		server *httptest.Server
+		mux    *http.ServeMux
+		client *SysdigClient
+	)
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		client = NewSysdigClient(server.URL, server.URL, "test-token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("sends the bearer token and decodes teams", func() {
+		mux.HandleFunc("/platform/v1/teams", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer test-token"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []SysdigTeam{{ID: 7, Name: "abc123-team"}},
+			})
+		})
+
+		teams, err := client.FetchTeams(ctx, "abc123-team")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(teams).To(ConsistOf(SysdigTeam{ID: 7, Name: "abc123-team"}))
+	})
+
+	It("returns the ID of a created user", func() {
+		mux.HandleFunc("/platform/v1/users", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			var body CreateUserRequest
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body.Email).To(Equal("jane.doe@gov.bc.ca"))
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(CreateUserResponse{ID: 42, Email: body.Email})
+		})
+
+		id, err := client.CreateUser(ctx, "jane.doe@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(int64(42)))
+	})
+
+	It("returns the existing user when the email is taken", func() {
+		mux.HandleFunc("/platform/v1/users", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			Expect(r.URL.Query().Get("filter")).To(Equal("email:jane.doe@gov.bc.ca"))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": []SysdigUser{{ID: 42, Email: "Jane.Doe@gov.bc.ca"}},
+			})
+		})
+
+		id, err := client.CreateUser(ctx, "jane.doe@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(int64(42)))
+	})
+
+	It("creates a team service account and returns its API key", func() {
+		expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		mux.HandleFunc("/platform/v1/teams/7/service-accounts", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			var body CreateServiceAccountRequest
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body).To(Equal(CreateServiceAccountRequest{
+				Name: "ci", TeamRole: "ROLE_TEAM_EDIT", ExpirationDate: expires.UnixMilli(),
+			}))
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(ServiceAccount{ID: 9, Name: body.Name, APIKey: "secret-key"})
+		})
+		mux.HandleFunc("/platform/v1/teams/7/service-accounts/9", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodDelete))
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		account, err := client.CreateServiceAccount(ctx, 7, "ci", "ROLE_TEAM_EDIT", expires)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(account.ID).To(Equal(int64(9)))
+		Expect(account.APIKey).To(Equal("secret-key"))
+		Expect(client.DeleteServiceAccount(ctx, 7, 9)).To(Succeed())
+	})
+
+	It("wraps alerts in an envelope on the Monitor endpoint", func() {
+		mux.HandleFunc("/api/v2/alerts", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			var body map[string]Alert
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body).To(HaveKey("alert"))
+			created := body["alert"]
+			created.ID, created.Version = 11, 1
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]Alert{"alert": created})
+		})
+
+		alert, err := client.CreateAlert(ctx, Alert{Type: AlertTypePrometheus, Name: "down", Condition: "up == 0"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(alert.ID).To(Equal(int64(11)))
+		Expect(alert.Condition).To(Equal("up == 0"))
+	})
+
+	It("keeps the panels of a dashboard when updating it", func() {
+		mux.HandleFunc("/api/v3/dashboards/21", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPut))
+			var body map[string]map[string]interface{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body["dashboard"]).To(HaveKeyWithValue("version", BeNumerically("==", 2)))
+			Expect(body["dashboard"]).To(HaveKeyWithValue("teamId", BeNumerically("==", 7)))
+			Expect(body["dashboard"]).To(HaveKey("panels"))
+			body["dashboard"]["version"] = 3
+			_ = json.NewEncoder(w).Encode(body)
+		})
+
+		d, err := ParseDashboard([]byte(`{"dashboard":{"id":5,"version":9,"name":"API","panels":[{"id":1}]}}`))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.ID).To(BeZero())
+		Expect(d.Name).To(Equal("API"))
+		d.ID, d.Version, d.TeamID = 21, 2, 7
+
+		updated, err := client.UpdateDashboard(ctx, d)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Version).To(Equal(int64(3)))
+	})
+
+	It("lists only the dashboards of the team", func() {
+		mux.HandleFunc("/api/v3/dashboards", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("light")).To(Equal("true"))
+			_, _ = w.Write([]byte(`{"dashboards":[` +
+				`{"id":1,"teamId":7,"name":"A","description":"[sysdig-operator template=a version=2]"},` +
+				`{"id":2,"teamId":8,"name":"B"}]}`))
+		})
+
+		dashboards, err := client.ListDashboards(ctx, 7)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(dashboards).To(HaveLen(1))
+		Expect(dashboards[0].ID).To(Equal(int64(1)))
+		name, version, ok := ParseDashboardMarker(dashboards[0].Description)
+		Expect(ok).To(BeTrue())
+		Expect(name).To(Equal("a"))
+		Expect(version).To(Equal(2))
+	})
+
+	It("writes dashboards with a cached team token, joining the team first", func() {
+		client.WithTeamTokens(TeamTokenConfig{User: "platform@gov.bc.ca"})
+		joined, exchanges := false, 0
+		mux.HandleFunc("/api/token/platform@gov.bc.ca/7", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer test-token"))
+			exchanges++
+			if !joined {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":{"key":"team-7-token"}}`))
+		})
+		mux.HandleFunc("/platform/v1/users", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(listResponse[SysdigUser]{
+				Data: []SysdigUser{{ID: 3, Email: "platform@gov.bc.ca"}},
+			})
+		})
+		mux.HandleFunc("/platform/v1/teams/7/users/3", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPut))
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer test-token"))
+			var body map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body).To(HaveKeyWithValue("standardTeamRole", DefaultTeamTokenRole))
+			joined = true
+			_, _ = w.Write([]byte(`{}`))
+		})
+		mux.HandleFunc("/api/v3/dashboards", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer team-7-token"))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"dashboard":{"id":21,"version":1,"teamId":7,"name":"API"}}`))
+		})
+
+		for range 2 {
+			_, err := client.CreateTeamDashboard(ctx, &Dashboard{TeamID: 7, Name: "API"})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(joined).To(BeTrue())
+		Expect(exchanges).To(Equal(2), "one miss before joining, then cached")
+	})
+
+	It("updates a team's scopes and keeps the fields it does not manage", func() {
+		var put map[string]interface{}
+		mux.HandleFunc("/platform/v1/teams/7", func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				_, _ = w.Write([]byte(`{"id":7,"version":3,"name":"abc123-team","product":"monitor",` +
+					`"isAllZones":true,"dateCreated":"2025-01-01T00:00:00Z",` +
+					`"scopes":[{"type":"AGENT","expression":"kubernetes.namespace.name in (\"abc123-tools\")"}]}`))
+			case http.MethodPut:
+				Expect(json.NewDecoder(r.Body).Decode(&put)).To(Succeed())
+			}
+		})
+
+		team, err := client.GetTeam(ctx, 7)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(team.Version).To(Equal(int64(3)))
+		team.Scopes = TeamScopes(BuildFilterExpression([]string{"abc123-tools", "abc123-dev"}))
+		Expect(client.UpdateTeam(ctx, team)).To(Succeed())
+
+		Expect(put).To(HaveKeyWithValue("version", BeNumerically("==", 3)))
+		Expect(put).To(HaveKeyWithValue("isAllZones", true))
+		Expect(put).NotTo(HaveKey("dateCreated"))
+		Expect(put).NotTo(HaveKey("product"))
+		Expect(put["scopes"]).To(ContainElement(HaveKeyWithValue("expression",
+			`kubernetes.namespace.name in ("abc123-tools","abc123-dev")`)))
+	})
+
+	It("treats 422 as a successful membership delete", func() {
+		mux.HandleFunc("/platform/v1/teams/7/users/42", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodDelete))
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		})
+
+		Expect(client.DeleteMembership(ctx, 7, 42)).To(Succeed())
+	})
+
+	It("returns a typed error carrying the Sysdig error body", func() {
+		mux.HandleFunc("/platform/v1/teams", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Request-Id", "req-1")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"type":"unprocessable_entity","message":"Teamless custom events not available in Secure","details":[]}`))
+		})
+
+		_, err := client.CreateTeam(ctx, "abc123-team-secure", "", "secure", TeamScopes(BuildFilterExpression([]string{"abc123-tools"})),
+			DefaultTeamPolicy().Secure.DefaultSettings())
+		Expect(IsUnprocessable(err)).To(BeTrue())
+		Expect(IsNotFound(err)).To(BeFalse())
+
+		apiErr, ok := AsAPIError(err)
+		Expect(ok).To(BeTrue())
+		Expect(apiErr.Operation).To(Equal("CreateTeam"))
+		Expect(apiErr.Type).To(Equal("unprocessable_entity"))
+		Expect(apiErr.RequestID).To(Equal("req-1"))
+		Expect(err.Error()).To(ContainSubstring("Teamless custom events not available in Secure"))
+	})
+
+	It("spaces writes according to the shared write budget", func() {
+		mux.HandleFunc("/platform/v1/teams/7/users/42", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		client.WithRateLimit(RateLimitConfig{WritesPerSecond: 20, WriteBurst: 1})
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			Expect(client.DeleteMembership(ctx, 7, 42)).To(Succeed())
+		}
+		// The first call uses the burst; the next two wait ~50ms each.
+		Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+	})
+})
@@ -1,52 +1,276 @@
 package helpers
 
 import (
-	"bytes"
-	_ "embed" // This import is required for //go:embed to work. The blank identifier is used to avoid an "unused import" error.
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
-	"strings"
-	"time"
+	"slices"
 )
 
-//go:embed template/dashboard-resources-approve.json.j2
-var dashboardTemplate []byte
+// CreateDashboard creates a dashboard from a catalog template in the team
+// data.TeamID.
+func (c *SysdigClient) CreateDashboard(ctx context.Context, t DashboardTemplate, data DashboardContext) (*Dashboard, error) {
+	d, err := t.Document(data)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/api/v3/dashboards", c.endpoints.Monitor)
+	return c.sendDashboard(ctx, "CreateDashboard", "POST", url, d, http.StatusCreated)
+}
+
+// Dashboard is a dashboard document from /api/v3/dashboards. The fields the
+// operator manages are typed; everything else, such as panels and layout,
+// is kept as it came and sent back unchanged.
+type Dashboard struct {
+	ID              int64
+	Version         int64
+	TeamID          int64
+	Name            string
+	Description     string
+	Public          bool
+	SharingSettings []DashboardShare
+
+	raw map[string]json.RawMessage
+}
+
+// DashboardShare gives a team or user a role on a dashboard, e.g.
+// ROLE_RESOURCE_READ.
+type DashboardShare struct {
+	Role   string               `json:"role"`
+	Member DashboardShareMember `json:"member"`
+}
+
+// DashboardShareMember is a TEAM or USER a dashboard is shared with.
+type DashboardShareMember struct {
+	Type string `json:"type"`
+	ID   int64  `json:"id"`
+}
 
-// CreateDashboard creates a new dashboard in Sysdig for a given team, based on an embedded template.
-func CreateDashboard(dashboardApiEndpoint, token string, teamID int64, targetNamespace string) error {
-	// Prepare the payload by replacing placeholders in the template.
-	replacer := strings.NewReplacer(
-		"__TEAM_ID__", strconv.FormatInt(teamID, 10),
-		"__TARGET_NAMESPACE__", targetNamespace,
-	)
-	payload := replacer.Replace(string(dashboardTemplate))
+// KeepFields copies untyped fields, e.g. "favorite", from another version
+// of the dashboard.
+func (d *Dashboard) KeepFields(from *Dashboard, keys ...string) {
+	if d.raw == nil {
+		d.raw = map[string]json.RawMessage{}
+	}
+	for _, k := range keys {
+		if v, ok := from.raw[k]; ok {
+			d.raw[k] = v
+		} else {
+			delete(d.raw, k)
+		}
+	}
+}
 
-	// Prepare the API request.
-	url := fmt.Sprintf("%s/api/v3/dashboards", dashboardApiEndpoint)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer([]byte(payload)))
+// dashboardFields are the typed fields of Dashboard.
+type dashboardFields struct {
+	ID              int64            `json:"id,omitempty"`
+	Version         int64            `json:"version,omitempty"`
+	TeamID          int64            `json:"teamId,omitempty"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	Public          bool             `json:"public"`
+	SharingSettings []DashboardShare `json:"sharingSettings"`
+}
+
+// ParseDashboard reads a dashboard document, either bare or wrapped in
+// {"dashboard": ...} as exported from Sysdig. Its ID and version are
+// dropped, so it can be created anywhere.
+func ParseDashboard(data []byte) (*Dashboard, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("dashboard is not a JSON object: %w", err)
+	}
+	if inner, ok := envelope["dashboard"]; ok && len(envelope) == 1 {
+		data = inner
+	}
+	var d Dashboard
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("dashboard is not a JSON object: %w", err)
+	}
+	d.ID, d.Version = 0, 0
+	return &d, nil
+}
+
+// UnmarshalJSON keeps the whole document next to the typed fields.
+func (d *Dashboard) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var f dashboardFields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	*d = Dashboard{
+		ID:              f.ID,
+		Version:         f.Version,
+		TeamID:          f.TeamID,
+		Name:            f.Name,
+		Description:     f.Description,
+		Public:          f.Public,
+		SharingSettings: f.SharingSettings,
+		raw:             raw,
+	}
+	return nil
+}
+
+// MarshalJSON writes the document with the typed fields laid over it.
+func (d Dashboard) MarshalJSON() ([]byte, error) {
+	typed, err := json.Marshal(dashboardFields{
+		ID:              d.ID,
+		Version:         d.Version,
+		TeamID:          d.TeamID,
+		Name:            d.Name,
+		Description:     d.Description,
+		Public:          d.Public,
+		SharingSettings: d.SharingSettings,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create dashboard request: %w", err)
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(typed, &fields); err != nil {
+		return nil, err
 	}
+	out := make(map[string]json.RawMessage, len(d.raw)+len(fields))
+	for k, v := range d.raw {
+		out[k] = v
+	}
+	delete(out, "id")
+	delete(out, "version")
+	for k, v := range fields {
+		out[k] = v
+	}
+	return json.Marshal(out)
+}
+
+// dashboardEnvelope wraps a Dashboard the way /api/v3/dashboards sends and expects it.
+type dashboardEnvelope struct {
+	Dashboard Dashboard `json:"dashboard"`
+}
+
+// CreateTeamDashboard creates a dashboard in the team given by d.TeamID and
+// returns it with its ID and version.
+func (c *SysdigClient) CreateTeamDashboard(ctx context.Context, d *Dashboard) (*Dashboard, error) {
+	url := fmt.Sprintf("%s/api/v3/dashboards", c.endpoints.Monitor)
+	return c.sendDashboard(ctx, "CreateTeamDashboard", "POST", url, d, http.StatusCreated, http.StatusOK)
+}
+
+// GetDashboard fetches a dashboard by ID.
+func (c *SysdigClient) GetDashboard(ctx context.Context, id int64) (*Dashboard, error) {
+	url := fmt.Sprintf("%s/api/v3/dashboards/%d", c.endpoints.Monitor, id)
+	return c.sendDashboard(ctx, "GetDashboard", "GET", url, nil, http.StatusOK)
+}
+
+// UpdateDashboard replaces a dashboard. d.Version must be the version last
+// read, or Sysdig rejects the update with a conflict.
+func (c *SysdigClient) UpdateDashboard(ctx context.Context, d *Dashboard) (*Dashboard, error) {
+	url := fmt.Sprintf("%s/api/v3/dashboards/%d", c.endpoints.Monitor, d.ID)
+	return c.sendDashboard(ctx, "UpdateDashboard", "PUT", url, d, http.StatusOK)
+}
 
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
+// ListDashboards returns the dashboards of a team. Dashboards are listed
+// without their panels, in the team's context when team tokens are on.
+func (c *SysdigClient) ListDashboards(ctx context.Context, teamID int64) ([]Dashboard, error) {
+	ctx, cancel := c.withTimeout(inTeam(ctx, teamID), "ListDashboards")
+	defer cancel()
 
-	// Execute the request.
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	url := fmt.Sprintf("%s/api/v3/dashboards?light=true", c.endpoints.Monitor)
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do("ListDashboards", req)
 	if err != nil {
-		return fmt.Errorf("failed to execute dashboard request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Check the response status code.
-	if resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("dashboard creation failed: status %d, body: %s", resp.StatusCode, string(bodyBytes))
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading ListDashboards response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("ListDashboards", resp, body)
+	}
+
+	var list struct {
+		Dashboards []Dashboard `json:"dashboards"`
 	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("parsing ListDashboards response JSON: %w", err)
+	}
+	var out []Dashboard
+	for _, d := range list.Dashboards {
+		if d.TeamID == teamID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
 
-	fmt.Printf("Successfully created dashboard for team ID %d\n", teamID)
+// DeleteDashboard deletes a dashboard by ID.
+func (c *SysdigClient) DeleteDashboard(ctx context.Context, id int64) error {
+	ctx, cancel := c.withTimeout(ctx, "DeleteDashboard")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/api/v3/dashboards/%d", c.endpoints.Monitor, id)
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do("DeleteDashboard", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("DeleteDashboard", resp, body)
+	}
 	return nil
 }
+
+// sendDashboard sends d, if any, wrapped in its envelope and decodes the
+// dashboard in the response.
+func (c *SysdigClient) sendDashboard(
+	ctx context.Context,
+	op, method, url string,
+	d *Dashboard,
+	okStatus ...int,
+) (*Dashboard, error) {
+	ctx, cancel := c.withTimeout(ctx, op)
+	defer cancel()
+
+	var payload interface{}
+	if d != nil {
+		payload = dashboardEnvelope{Dashboard: *d}
+		ctx = inTeam(ctx, d.TeamID)
+	}
+	req, err := c.newRequest(ctx, method, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(op, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s response body: %w", op, err)
+	}
+	if !slices.Contains(okStatus, resp.StatusCode) {
+		return nil, newAPIError(op, resp, body)
+	}
+
+	var envelope dashboardEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("parsing %s response JSON: %w", op, err)
+	}
+	return &envelope.Dashboard, nil
+}
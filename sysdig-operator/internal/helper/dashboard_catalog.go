@@ -0,0 +1,323 @@
+package helpers
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed template/*.json.j2
+var dashboardTemplates embed.FS
+
+// DashboardTemplate describes one version of a dashboard of the embedded
+// catalog. Its file is template/<name>.v<version>.json.j2.
+type DashboardTemplate struct {
+	// Name is how teams pick the dashboard in spec.team.dashboards.
+	Name string
+	// Version is raised whenever the template changes. Older versions stay
+	// in the catalog, so dashboards can be rolled back to them.
+	Version     int
+	Description string
+	// Variables are the DashboardContext fields the template needs.
+	// Rendering fails when one of them is empty.
+	Variables []string
+}
+
+// dashboardCatalog lists every version of the embedded dashboard templates.
+var dashboardCatalog = []DashboardTemplate{
+	{
+		Name:        "resources-approve",
+		Version:     1,
+		Description: "CPU, memory and storage usage against quota, for resource quota requests",
+		Variables:   []string{"TeamID", "ProdNamespace", "ComputeQuota"},
+	},
+	{
+		Name:        "resource-allocation",
+		Version:     1,
+		Description: "Usage against requests and limits per workload",
+		Variables:   []string{"TeamID", "ProdNamespace"},
+	},
+	{
+		Name:        "workload-stability-scaling",
+		Version:     1,
+		Description: "CPU throttling, restarts, OOM kills and replica counts",
+		Variables:   []string{"TeamID", "ProdNamespace"},
+	},
+}
+
+// DefaultDashboards are created for teams that do not list any, unless the
+// platform configures its own list.
+var DefaultDashboards = []string{"resources-approve"}
+
+// DashboardCatalog returns every version of the embedded dashboard templates.
+func DashboardCatalog() []DashboardTemplate {
+	return append([]DashboardTemplate(nil), dashboardCatalog...)
+}
+
+// LookupDashboardTemplate returns the latest version of the catalog
+// template with the given name.
+func LookupDashboardTemplate(name string) (DashboardTemplate, error) {
+	var latest DashboardTemplate
+	for _, t := range dashboardCatalog {
+		if t.Name == name && t.Version > latest.Version {
+			latest = t
+		}
+	}
+	if latest.Version == 0 {
+		return DashboardTemplate{}, fmt.Errorf("dashboard %q is not in the catalog", name)
+	}
+	return latest, nil
+}
+
+// LookupDashboardTemplateVersion returns one version of a catalog template.
+func LookupDashboardTemplateVersion(name string, version int) (DashboardTemplate, error) {
+	for _, t := range dashboardCatalog {
+		if t.Name == name && t.Version == version {
+			return t, nil
+		}
+	}
+	return DashboardTemplate{}, fmt.Errorf("dashboard %q has no version %d in the catalog", name, version)
+}
+
+func (t DashboardTemplate) file() string {
+	return fmt.Sprintf("template/%s.v%d.json.j2", t.Name, t.Version)
+}
+
+// Default quota names of the platform project sets.
+const (
+	DefaultComputeQuota = "compute-long-running-quota"
+	DefaultStorageQuota = "storage-quota"
+)
+
+// DashboardContext is what a dashboard template can refer to, e.g.
+// {% .ProdNamespace %}. Templates use {% %} as delimiters, since
+// Sysdig panel legends already use {{ }}.
+type DashboardContext struct {
+	// TeamID is the Monitor team the dashboard is created in.
+	TeamID         int64
+	TeamName       string
+	SecureTeamName string
+	NSPrefix       string
+	// Namespaces are the namespaces the team is scoped to.
+	Namespaces    []string
+	ProdNamespace string
+	// ClusterName is the kubernetes.cluster.name of this cluster, if known.
+	ClusterName  string
+	ComputeQuota string
+	StorageQuota string
+}
+
+// NewDashboardContext returns the context of the team facts describe,
+// without a team ID.
+func NewDashboardContext(facts TeamFacts, clusterName string) DashboardContext {
+	return DashboardContext{
+		TeamName:       facts.ContainerTeamName,
+		SecureTeamName: facts.ContainerSecureTeamName,
+		NSPrefix:       facts.NSPrefix,
+		Namespaces:     facts.Namespaces,
+		ProdNamespace:  facts.ProdNamespace,
+		ClusterName:    clusterName,
+		ComputeQuota:   facts.ComputeQuota,
+		StorageQuota:   facts.StorageQuota,
+	}
+}
+
+// templateFuncs are the functions available to dashboard templates.
+var templateFuncs = template.FuncMap{
+	// json renders a value as a JSON literal, e.g. a quoted string.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// promql renders a string as a single-quoted PromQL string literal,
+	// escaped for use inside a JSON string such as a panel query.
+	"promql": func(s string) (string, error) {
+		literal := "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+		data, err := json.Marshal(literal)
+		if err != nil {
+			return "", err
+		}
+		return string(data[1 : len(data)-1]), nil
+	},
+	// list returns its non-empty arguments as a list.
+	"list": func(values ...string) []string {
+		out := []string{}
+		for _, v := range values {
+			if v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	},
+}
+
+// Render executes the template with data and checks that the result is
+// valid JSON. Every variable the template declares must be set.
+func (t DashboardTemplate) Render(data DashboardContext) ([]byte, error) {
+	fields := reflect.ValueOf(data)
+	for _, name := range t.Variables {
+		field := fields.FieldByName(name)
+		if !field.IsValid() {
+			return nil, fmt.Errorf("dashboard template %s declares unknown variable %s", t.Name, name)
+		}
+		if field.IsZero() {
+			return nil, fmt.Errorf("dashboard template %s needs variable %s", t.Name, name)
+		}
+	}
+
+	src, err := dashboardTemplates.ReadFile(t.file())
+	if err != nil {
+		return nil, fmt.Errorf("read dashboard template %s: %w", t.Name, err)
+	}
+	return renderDashboard(t.Name, string(src), data)
+}
+
+// Document renders the template into a dashboard whose description ends
+// with the template's marker.
+func (t DashboardTemplate) Document(data DashboardContext) (*Dashboard, error) {
+	rendered, err := t.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	d, err := ParseDashboard(rendered)
+	if err != nil {
+		return nil, fmt.Errorf("dashboard template %s: %w", t.Name, err)
+	}
+	marker := DashboardMarker(t.Name, t.Version)
+	if d.Description == "" {
+		d.Description = marker
+	} else {
+		d.Description = strings.TrimRight(d.Description, "\n") + "\n\n" + marker
+	}
+	return d, nil
+}
+
+// markerPattern matches the marker DashboardMarker writes.
+var markerPattern = regexp.MustCompile(`\[sysdig-operator template=([a-z0-9-]+) version=([0-9]+)\]`)
+
+// DashboardMarker returns the text that marks a dashboard as rendered from
+// a catalog template. It is kept in the dashboard description, so it
+// survives edits in the Sysdig UI.
+func DashboardMarker(name string, version int) string {
+	return fmt.Sprintf("[sysdig-operator template=%s version=%d]", name, version)
+}
+
+// ParseDashboardMarker finds the marker in a dashboard description.
+func ParseDashboardMarker(description string) (name string, version int, ok bool) {
+	m := markerPattern.FindStringSubmatch(description)
+	if m == nil {
+		return "", 0, false
+	}
+	version, err := strconv.Atoi(m[2])
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], version, true
+}
+
+// renderDashboard executes a dashboard template and checks that the result
+// is valid JSON. Referring to a field DashboardContext does not have fails.
+func renderDashboard(name, src string, data DashboardContext) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Delims("{%", "%}").
+		Funcs(templateFuncs).
+		Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse dashboard template %s: %w", name, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("render dashboard template %s: %w", name, err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		return nil, fmt.Errorf("dashboard template %s rendered invalid JSON: %w", name, err)
+	}
+	return out.Bytes(), nil
+}
+
+// ParseDashboardNames parses a comma-separated list of catalog dashboards.
+// An empty list gives an empty, non-nil slice, so it is not mistaken for
+// "use the defaults".
+func ParseDashboardNames(s string) ([]string, error) {
+	out := []string{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, err := LookupDashboardTemplate(name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// DashboardRollout controls how new template versions reach the dashboards
+// of existing teams.
+type DashboardRollout struct {
+	// Percent is the share of teams whose dashboards are upgraded to the
+	// latest version. Teams are picked by a hash of their name, so raising
+	// it only adds teams.
+	Percent int
+	// Pins fix a template to a version for every team, e.g. to roll back.
+	Pins map[string]int
+}
+
+// DefaultDashboardRollout upgrades every team right away.
+func DefaultDashboardRollout() DashboardRollout {
+	return DashboardRollout{Percent: 100}
+}
+
+// ParseDashboardPins parses a comma-separated list of name=version pins,
+// e.g. "resources-approve=1".
+func ParseDashboardPins(s string) (map[string]int, error) {
+	pins := map[string]int{}
+	for _, pin := range strings.Split(s, ",") {
+		if pin = strings.TrimSpace(pin); pin == "" {
+			continue
+		}
+		name, v, ok := strings.Cut(pin, "=")
+		version, err := strconv.Atoi(strings.TrimSpace(v))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("dashboard pin %q is not name=version", pin)
+		}
+		name = strings.TrimSpace(name)
+		if _, err := LookupDashboardTemplateVersion(name, version); err != nil {
+			return nil, err
+		}
+		pins[name] = version
+	}
+	return pins, nil
+}
+
+// Target returns the version of a template a team's dashboard should have,
+// given the version it has now, 0 for none. New dashboards get the latest
+// version; existing ones are upgraded once the team is in the rollout.
+func (r DashboardRollout) Target(team, name string, current int) int {
+	if pinned, ok := r.Pins[name]; ok {
+		return pinned
+	}
+	latest, err := LookupDashboardTemplate(name)
+	if err != nil {
+		return current
+	}
+	if current == 0 || current > latest.Version || r.includes(team) {
+		return latest.Version
+	}
+	return current
+}
+
+// includes reports whether a team is in the rollout.
+func (r DashboardRollout) includes(team string) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(team))
+	return int(h.Sum32()%100) < r.Percent
+}
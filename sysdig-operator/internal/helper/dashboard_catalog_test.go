@@ -0,0 +1,118 @@
+package helpers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dashboard catalog", func() {
+	data := NewDashboardContext(SetTeamFacts("abc123-tools"), "silver")
+	data.TeamID = 42
+
+	It("renders every template to a dashboard of the team", func() {
+		for _, t := range DashboardCatalog() {
+			rendered, err := t.Render(data)
+			Expect(err).NotTo(HaveOccurred(), t.Name)
+			Expect(string(rendered)).NotTo(ContainSubstring("{%"), t.Name)
+			Expect(string(rendered)).To(ContainSubstring(`"abc123-prod"`), t.Name)
+
+			d, err := ParseDashboard(rendered)
+			Expect(err).NotTo(HaveOccurred(), t.Name)
+			Expect(d.TeamID).To(Equal(int64(42)), t.Name)
+			Expect(d.SharingSettings).To(ConsistOf(HaveField("Member.ID", int64(42))), t.Name)
+		}
+	})
+
+	It("needs every declared variable", func() {
+		t, err := LookupDashboardTemplate("resources-approve")
+		Expect(err).NotTo(HaveOccurred())
+		missing := data
+		missing.ComputeQuota = ""
+		_, err = t.Render(missing)
+		Expect(err).To(MatchError(ContainSubstring("needs variable ComputeQuota")))
+	})
+
+	It("renders quota names from the facts as escaped PromQL strings", func() {
+		facts := SetTeamFacts("abc123-tools")
+		Expect(NewDashboardContext(facts, "").ComputeQuota).To(Equal(DefaultComputeQuota))
+		facts.ComputeQuota = `it's "odd"`
+		rendered, err := renderDashboard("test", `{"query": "q{name={% promql .ComputeQuota %}}"}`,
+			NewDashboardContext(facts, ""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(rendered)).To(Equal(`{"query": "q{name='it\\'s \"odd\"'}"}`))
+
+		t, err := LookupDashboardTemplate("resources-approve")
+		Expect(err).NotTo(HaveOccurred())
+		rendered, err = t.Render(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(rendered)).To(ContainSubstring(`kube_resourcequota_name='compute-long-running-quota'`))
+	})
+
+	It("fails on undefined variables and invalid JSON", func() {
+		_, err := renderDashboard("test", `{"teamId": {% .TeamId %}}`, data)
+		Expect(err).To(MatchError(ContainSubstring("can't evaluate field TeamId")))
+
+		_, err = renderDashboard("test", `{"namespaces": {% .Namespaces %}}`, data)
+		Expect(err).To(MatchError(ContainSubstring("rendered invalid JSON")))
+		rendered, err := renderDashboard("test", `{"namespaces": {% json .Namespaces %}, "cluster": {% json (list "") %}}`, data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(rendered)).To(Equal(
+			`{"namespaces": ["abc123-tools","abc123-dev","abc123-test","abc123-prod"], "cluster": []}`))
+	})
+
+	It("takes the prod namespace from the team scope", func() {
+		facts := SetTeamFacts("abc123-tools")
+		facts.SetNamespaces([]string{"abc123-tools", "abc123-dev"})
+		Expect(facts.ProdNamespace).To(BeEmpty())
+		facts.SetNamespaces([]string{"abc123-tools", "abc123-prod"})
+		Expect(facts.ProdNamespace).To(Equal("abc123-prod"))
+	})
+
+	It("rejects unknown default dashboards", func() {
+		Expect(ParseDashboardNames(" resources-approve, resource-allocation ")).To(
+			Equal([]string{"resources-approve", "resource-allocation"}))
+		_, err := ParseDashboardNames("resources-approve,nope")
+		Expect(err).To(MatchError(ContainSubstring(`"nope"`)))
+	})
+
+	It("keeps an empty default dashboard list apart from an unset one", func() {
+		names, err := ParseDashboardNames("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).NotTo(BeNil())
+		Expect(names).To(BeEmpty())
+	})
+
+	It("marks rendered dashboards with their template version", func() {
+		t, err := LookupDashboardTemplate("resources-approve")
+		Expect(err).NotTo(HaveOccurred())
+		d, err := t.Document(data)
+		Expect(err).NotTo(HaveOccurred())
+		name, version, ok := ParseDashboardMarker("Edited in the UI\n" + d.Description)
+		Expect(ok).To(BeTrue())
+		Expect(name).To(Equal("resources-approve"))
+		Expect(version).To(Equal(t.Version))
+
+		_, _, ok = ParseDashboardMarker("no marker")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("upgrades teams in the rollout and follows pins", func() {
+		latest, err := LookupDashboardTemplate("resources-approve")
+		Expect(err).NotTo(HaveOccurred())
+
+		none := DashboardRollout{Percent: 0}
+		Expect(none.Target("abc123-team", "resources-approve", 0)).To(Equal(latest.Version))
+		Expect(none.Target("abc123-team", "resources-approve", 1)).To(Equal(1))
+		Expect(none.Target("abc123-team", "resources-approve", latest.Version+1)).To(Equal(latest.Version))
+		Expect(DefaultDashboardRollout().Target("abc123-team", "resources-approve", 0)).To(Equal(latest.Version))
+
+		pinned := DashboardRollout{Percent: 100, Pins: map[string]int{"resources-approve": 1}}
+		Expect(pinned.Target("abc123-team", "resources-approve", 2)).To(Equal(1))
+
+		Expect(ParseDashboardPins("resources-approve=1")).To(Equal(map[string]int{"resources-approve": 1}))
+		_, err = ParseDashboardPins("resources-approve=99")
+		Expect(err).To(MatchError(ContainSubstring("no version 99")))
+		_, err = ParseDashboardPins("resources-approve")
+		Expect(err).To(MatchError(ContainSubstring("name=version")))
+	})
+})
@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RegionOnPrem selects a self-hosted Sysdig backend. All APIs are served from
+// the platform endpoint, which then has to be configured explicitly.
+const RegionOnPrem = "on-prem"
+
+// DefaultRegion is assumed when no region is configured. It matches the
+// endpoints the operator used before regions were configurable.
+const DefaultRegion = "us1"
+
+// endpointCheckTimeout bounds each startup reachability check.
+const endpointCheckTimeout = 10 * time.Second
+
+// Endpoints holds the base URLs of the Sysdig APIs the operator talks to.
+type Endpoints struct {
+	// Monitor serves the Monitor API, e.g. /api/v3/dashboards.
+	Monitor string
+	// Secure serves the Secure API.
+	Secure string
+	// Platform serves the platform API, e.g. /platform/v1/teams.
+	Platform string
+}
+
+// regions maps the Sysdig SaaS regions onto their API endpoints.
+// https://docs.sysdig.com/en/docs/administration/saas-regions-and-ip-ranges/
+var regions = map[string]Endpoints{
+	"us1": {
+		Monitor:  "https://app.sysdigcloud.com",
+		Secure:   "https://secure.sysdig.com",
+		Platform: "https://api.us1.sysdig.com",
+	},
+	"us2": {
+		Monitor:  "https://us2.app.sysdig.com",
+		Secure:   "https://us2.app.sysdig.com",
+		Platform: "https://api.us2.sysdig.com",
+	},
+	"eu1": {
+		Monitor:  "https://eu1.app.sysdig.com",
+		Secure:   "https://eu1.app.sysdig.com",
+		Platform: "https://api.eu1.sysdig.com",
+	},
+	"au1": {
+		Monitor:  "https://app.au1.sysdig.com",
+		Secure:   "https://app.au1.sysdig.com",
+		Platform: "https://api.au1.sysdig.com",
+	},
+}
+
+// Regions returns the names of the known SaaS regions, sorted.
+func Regions() []string {
+	names := make([]string, 0, len(regions))
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveEndpoints derives the Monitor, Secure and Platform base URLs from a
+// region name. Non-empty fields of overrides replace the derived values, so
+// SYSDIG_API_ENDPOINT and SYSDIG_DASHBOARD_API_ENDPOINT keep working. For
+// RegionOnPrem, overrides.Platform is required and is used for every API
+// that is not overridden.
+func ResolveEndpoints(region string, overrides Endpoints) (Endpoints, error) {
+	region = strings.ToLower(strings.TrimSpace(region))
+	if region == "" {
+		region = DefaultRegion
+	}
+
+	var resolved Endpoints
+	if region == RegionOnPrem {
+		if overrides.Platform == "" {
+			return Endpoints{}, errors.New("region on-prem needs an explicit platform API endpoint")
+		}
+		resolved = Endpoints{Monitor: overrides.Platform, Secure: overrides.Platform, Platform: overrides.Platform}
+	} else {
+		var ok bool
+		if resolved, ok = regions[region]; !ok {
+			return Endpoints{}, fmt.Errorf("unknown Sysdig region %q, want one of %s or %s",
+				region, strings.Join(Regions(), ", "), RegionOnPrem)
+		}
+	}
+
+	if overrides.Monitor != "" {
+		resolved.Monitor = overrides.Monitor
+	}
+	if overrides.Secure != "" {
+		resolved.Secure = overrides.Secure
+	}
+	if overrides.Platform != "" {
+		resolved.Platform = overrides.Platform
+	}
+
+	for name, base := range resolved.byName() {
+		u, err := url.Parse(base)
+		if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+			return Endpoints{}, fmt.Errorf("invalid %s endpoint %q", name, base)
+		}
+	}
+	resolved.Monitor = strings.TrimRight(resolved.Monitor, "/")
+	resolved.Secure = strings.TrimRight(resolved.Secure, "/")
+	resolved.Platform = strings.TrimRight(resolved.Platform, "/")
+	return resolved, nil
+}
+
+// byName returns the endpoints keyed by API name, for error messages.
+func (e Endpoints) byName() map[string]string {
+	return map[string]string{"monitor": e.Monitor, "secure": e.Secure, "platform": e.Platform}
+}
+
+// CheckReachable verifies that every endpoint answers HTTP requests. Any
+// response counts, including 401 and 404: the check is about DNS, TLS and
+// routing, not credentials. Endpoints shared by several APIs are checked once.
+func (e Endpoints) CheckReachable(ctx context.Context, hc *http.Client) error {
+	checked := map[string]bool{}
+	var errs []error
+	for _, name := range []string{"platform", "monitor", "secure"} {
+		base := e.byName()[name]
+		if checked[base] {
+			continue
+		}
+		checked[base] = true
+		if err := checkReachable(ctx, hc, base); err != nil {
+			errs = append(errs, fmt.Errorf("%s endpoint %s is not reachable: %w", name, base, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkReachable(ctx context.Context, hc *http.Client, base string) error {
+	ctx, cancel := context.WithTimeout(ctx, endpointCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+	return nil
+}
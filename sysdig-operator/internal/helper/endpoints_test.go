@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Endpoints", func() {
+	It("defaults to the us1 endpoints the operator has always used", func() {
+		endpoints, err := ResolveEndpoints("", Endpoints{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal(Endpoints{
+			Monitor:  "https://app.sysdigcloud.com",
+			Secure:   "https://secure.sysdig.com",
+			Platform: "https://api.us1.sysdig.com",
+		}))
+	})
+
+	It("derives every endpoint from the region", func() {
+		endpoints, err := ResolveEndpoints("EU1", Endpoints{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints.Monitor).To(Equal("https://eu1.app.sysdig.com"))
+		Expect(endpoints.Platform).To(Equal("https://api.eu1.sysdig.com"))
+	})
+
+	It("lets explicit endpoints override the region", func() {
+		endpoints, err := ResolveEndpoints("au1", Endpoints{Platform: "https://proxy.example.com/"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints.Platform).To(Equal("https://proxy.example.com"))
+		Expect(endpoints.Monitor).To(Equal("https://app.au1.sysdig.com"))
+	})
+
+	It("serves every API from the platform endpoint on-prem", func() {
+		_, err := ResolveEndpoints(RegionOnPrem, Endpoints{})
+		Expect(err).To(HaveOccurred())
+
+		endpoints, err := ResolveEndpoints(RegionOnPrem, Endpoints{Platform: "https://sysdig.apps.example.com"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal(Endpoints{
+			Monitor:  "https://sysdig.apps.example.com",
+			Secure:   "https://sysdig.apps.example.com",
+			Platform: "https://sysdig.apps.example.com",
+		}))
+	})
+
+	It("rejects unknown regions and malformed URLs", func() {
+		_, err := ResolveEndpoints("mars1", Endpoints{})
+		Expect(err).To(MatchError(ContainSubstring("unknown Sysdig region")))
+
+		_, err = ResolveEndpoints("us1", Endpoints{Monitor: "app.sysdigcloud.com"})
+		Expect(err).To(MatchError(ContainSubstring("invalid monitor endpoint")))
+	})
+
+	It("checks that every endpoint answers, whatever the status", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+		down := httptest.NewServer(http.NotFoundHandler())
+		down.Close()
+
+		up := Endpoints{Monitor: server.URL, Secure: server.URL, Platform: server.URL}
+		Expect(up.CheckReachable(ctx, server.Client())).To(Succeed())
+
+		partial := Endpoints{Monitor: server.URL, Secure: down.URL, Platform: server.URL}
+		err := partial.CheckReachable(ctx, server.Client())
+		Expect(err).To(MatchError(ContainSubstring("secure endpoint " + down.URL)))
+	})
+})
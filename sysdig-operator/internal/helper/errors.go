@@ -0,0 +1,142 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SysdigAPIError is returned when the Sysdig API answers with an unexpected
+// status code. It carries the decoded Sysdig error body, e.g.
+// {"type":"unprocessable_entity","message":"...","details":[]}.
+type SysdigAPIError struct {
+	// Operation is the client operation that failed, e.g. "CreateTeam".
+	Operation  string
+	StatusCode int
+	Type       string
+	Message    string
+	Details    []json.RawMessage
+	RequestID  string
+	// RetryAfter is the delay requested by the API on 429/503 responses, if any.
+	RetryAfter time.Duration
+	// Body is the raw response body, kept when it is not a Sysdig error document.
+	Body string
+}
+
+// sysdigErrorBody is the error document returned by the platform API.
+type sysdigErrorBody struct {
+	Type    string            `json:"type"`
+	Message string            `json:"message"`
+	Details []json.RawMessage `json:"details"`
+}
+
+// newAPIError builds a SysdigAPIError from a response whose body was already read.
+func newAPIError(op string, resp *http.Response, body []byte) *SysdigAPIError {
+	apiErr := &SysdigAPIError{
+		Operation:  op,
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	if d, ok := retryAfter(resp); ok {
+		apiErr.RetryAfter = d
+	}
+
+	var decoded sysdigErrorBody
+	if err := json.Unmarshal(body, &decoded); err == nil && (decoded.Type != "" || decoded.Message != "") {
+		apiErr.Type = decoded.Type
+		apiErr.Message = decoded.Message
+		apiErr.Details = decoded.Details
+	} else {
+		apiErr.Body = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+func (e *SysdigAPIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: status %d", e.Operation, e.StatusCode)
+	if e.Type != "" {
+		fmt.Fprintf(&b, " (%s)", e.Type)
+	}
+	switch {
+	case e.Message != "":
+		fmt.Fprintf(&b, ": %s", e.Message)
+	case e.Body != "":
+		fmt.Fprintf(&b, ", body %s", e.Body)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request id %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+// AsAPIError unwraps err into a *SysdigAPIError, if it is one.
+func AsAPIError(err error) (*SysdigAPIError, bool) {
+	var apiErr *SysdigAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// hasStatus reports whether err is a SysdigAPIError with one of the given codes.
+func hasStatus(err error, codes ...int) bool {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return false
+	}
+	for _, code := range codes {
+		if apiErr.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotFound reports a 404 from the Sysdig API.
+func IsNotFound(err error) bool { return hasStatus(err, http.StatusNotFound) }
+
+// IsConflict reports a 409 from the Sysdig API, e.g. a duplicate name or email.
+func IsConflict(err error) bool { return hasStatus(err, http.StatusConflict) }
+
+// IsUnauthorized reports a 401 or 403, i.e. a bad or under-privileged token.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized, http.StatusForbidden)
+}
+
+// IsUnprocessable reports a 422, which Sysdig uses for validation failures.
+func IsUnprocessable(err error) bool { return hasStatus(err, http.StatusUnprocessableEntity) }
+
+// IsBadRequest reports a 400 from the Sysdig API.
+func IsBadRequest(err error) bool { return hasStatus(err, http.StatusBadRequest) }
+
+// IsThrottled reports a 429 from the Sysdig API.
+func IsThrottled(err error) bool { return hasStatus(err, http.StatusTooManyRequests) }
+
+// IsServerError reports any 5xx from the Sysdig API.
+func IsServerError(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode >= 500
+}
+
+// AmbiguousMatchError is returned by the Find* lookups when more than one
+// Sysdig object matches the requested name or email exactly.
+type AmbiguousMatchError struct {
+	// Kind is the object type, "team" or "user".
+	Kind string
+	Key  string
+	IDs  []int64
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("%d Sysdig %ss match %q exactly (IDs %v)", len(e.IDs), e.Kind, e.Key, e.IDs)
+}
+
+// IsAmbiguous reports whether err is an AmbiguousMatchError.
+func IsAmbiguous(err error) bool {
+	var ambiguous *AmbiguousMatchError
+	return errors.As(err, &ambiguous)
+}
@@ -1,6 +1,9 @@
 package helpers
 
-import "strings"
+import (
+	"slices"
+	"strings"
+)
 
 // TeamFacts holds computed values for team namespaces and names.
 type TeamFacts struct {
@@ -10,6 +13,8 @@ type TeamFacts struct {
 	ContainerTeamName       string
 	ContainerSecureTeamName string
 	HostTeamName            string
+	ComputeQuota            string
+	StorageQuota            string
 	ContainerTeamExists     bool
 	HostTeamExists          bool
 }
@@ -36,7 +41,19 @@ func SetTeamFacts(namespace string) TeamFacts {
 		ContainerTeamName:       nsPrefix + "-team",
 		ContainerSecureTeamName: nsPrefix + "-team-secure",
 		HostTeamName:            nsPrefix + "-team-persistent-storage",
+		ComputeQuota:            DefaultComputeQuota,
+		StorageQuota:            DefaultStorageQuota,
 		ContainerTeamExists:     false,
 		HostTeamExists:          false,
 	}
 }
+
+// SetNamespaces records the namespaces the teams are scoped to.
+// ProdNamespace is cleared when the -prod namespace is not one of them.
+func (f *TeamFacts) SetNamespaces(namespaces []string) {
+	f.Namespaces = namespaces
+	f.ProdNamespace = ""
+	if prod := f.NSPrefix + "-prod"; slices.Contains(namespaces, prod) {
+		f.ProdNamespace = prod
+	}
+}
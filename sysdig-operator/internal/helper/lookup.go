@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"context"
+	"iter"
+	"strings"
+)
+
+// FindTeamByName returns the team whose name equals name, ignoring case.
+// The name filter only narrows the listing; results are matched exactly so a
+// team like "abc-team-secure" never satisfies a lookup for "abc-team".
+// It returns nil when no team matches and an *AmbiguousMatchError when
+// several do.
+func (c *SysdigClient) FindTeamByName(ctx context.Context, name string) (*SysdigTeam, error) {
+	return findOne(c.IterTeams(ctx, ListOptions{Filter: "name:" + name}), "team", name,
+		func(t SysdigTeam) (string, int64) { return t.Name, t.ID })
+}
+
+// FindUserByEmail returns the user whose email equals email, ignoring case.
+// It returns nil when no user matches and an *AmbiguousMatchError when
+// several do.
+func (c *SysdigClient) FindUserByEmail(ctx context.Context, email string) (*SysdigUser, error) {
+	return findOne(c.IterUsers(ctx, ListOptions{Filter: "email:" + email}), "user", email,
+		func(u SysdigUser) (string, int64) { return u.Email, u.ID })
+}
+
+// findOne scans a filtered listing for exact, case-insensitive matches on key.
+func findOne[T any](seq iter.Seq2[T, error], kind, key string, fields func(T) (string, int64)) (*T, error) {
+	var found *T
+	var ids []int64
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		value, id := fields(item)
+		if !strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(key)) {
+			continue
+		}
+		ids = append(ids, id)
+		if found == nil {
+			match := item
+			found = &match
+		}
+	}
+	if len(ids) > 1 {
+		return nil, &AmbiguousMatchError{Kind: kind, Key: key, IDs: ids}
+	}
+	return found, nil
+}
@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lookups", func() {
+	var (
+		server  *httptest.Server
+		client  *SysdigClient
+		filters []string
+		users   []SysdigUser
+		teams   []SysdigTeam
+	)
+
+	BeforeEach(func() {
+		filters = nil
+		mux := http.NewServeMux()
+		mux.HandleFunc("/platform/v1/users", func(w http.ResponseWriter, r *http.Request) {
+			filters = append(filters, r.URL.Query().Get("filter"))
+			_ = json.NewEncoder(w).Encode(listResponse[SysdigUser]{Data: users})
+		})
+		mux.HandleFunc("/platform/v1/teams", func(w http.ResponseWriter, r *http.Request) {
+			filters = append(filters, r.URL.Query().Get("filter"))
+			_ = json.NewEncoder(w).Encode(listResponse[SysdigTeam]{Data: teams})
+		})
+		server = httptest.NewServer(mux)
+		client = NewSysdigClient(server.URL, "", "token")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("escapes the email filter and ignores substring matches", func() {
+		users = []SysdigUser{
+			{ID: 1, Email: "jane.doe+ops@gov.bc.ca.example"},
+			{ID: 2, Email: "Jane.Doe+ops@gov.bc.ca"},
+		}
+
+		user, err := client.FindUserByEmail(ctx, "jane.doe+ops@gov.bc.ca")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(user).To(Equal(&SysdigUser{ID: 2, Email: "Jane.Doe+ops@gov.bc.ca"}))
+		Expect(filters).To(ConsistOf("email:jane.doe+ops@gov.bc.ca"))
+	})
+
+	It("returns nil when no team matches exactly", func() {
+		teams = []SysdigTeam{{ID: 1, Name: "abc123-team-secure"}}
+
+		team, err := client.FindTeamByName(ctx, "abc123-team")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(team).To(BeNil())
+	})
+
+	It("reports ambiguous matches instead of picking one", func() {
+		teams = []SysdigTeam{{ID: 1, Name: "abc123-team"}, {ID: 2, Name: "ABC123-team"}}
+
+		_, err := client.FindTeamByName(ctx, "abc123-team")
+		Expect(IsAmbiguous(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("IDs [1 2]"))
+	})
+})
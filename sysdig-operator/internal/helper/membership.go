@@ -1,12 +1,11 @@
 package helpers
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
-	"time"
 )
 
 // TeamMembership represents one user’s membership on a team
@@ -15,54 +14,29 @@ type TeamMembership struct {
 	Role   string `json:"standardTeamRole"` // the role field
 }
 
-// FetchTeamMemberships fetches current user memberships for the given team.
-func FetchTeamMemberships(apiEndpoint, token string, teamID int64) ([]TeamMembership, error) {
-	url := fmt.Sprintf("%s/platform/v1/teams/%d/users", apiEndpoint, teamID)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("FetchTeamMemberships: status %d, body %s", resp.StatusCode, string(b))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+// IterTeamMemberships streams the memberships of a team, walking every page.
+func (c *SysdigClient) IterTeamMemberships(ctx context.Context, teamID int64, opts ListOptions) iter.Seq2[TeamMembership, error] {
+	endpoint := fmt.Sprintf("%s/platform/v1/teams/%d/users", c.endpoints.Platform, teamID)
+	return paginate[TeamMembership](ctx, c, "FetchTeamMemberships", endpoint, opts)
+}
 
-	// The API returns { "page": {...}, "data": [ {userId, standardTeamRole, ...}, ... ] }
-	var wrapper struct {
-		Data []TeamMembership `json:"data"`
-	}
-	if err := json.Unmarshal(body, &wrapper); err != nil {
-		return nil, fmt.Errorf("unmarshal memberships: %w", err)
-	}
-	return wrapper.Data, nil
+// FetchTeamMemberships fetches all current user memberships for the given team.
+func (c *SysdigClient) FetchTeamMemberships(ctx context.Context, teamID int64) ([]TeamMembership, error) {
+	return collect(c.IterTeamMemberships(ctx, teamID, ListOptions{}))
 }
 
 // DeleteMembership removes a user from a Sysdig team.
-func DeleteMembership(apiEndpoint, token string, teamID, userID int64) error {
+func (c *SysdigClient) DeleteMembership(ctx context.Context, teamID, userID int64) error {
+	ctx, cancel := c.withTimeout(ctx, "DeleteMembership")
+	defer cancel()
+
 	url := fmt.Sprintf("%s/platform/v1/teams/%d/users/%d",
-		apiEndpoint, teamID, userID)
-	req, err := http.NewRequest("DELETE", url, nil)
+		c.endpoints.Platform, teamID, userID)
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := c.do("DeleteMembership", req)
 	if err != nil {
 		return err
 	}
@@ -70,8 +44,7 @@ func DeleteMembership(apiEndpoint, token string, teamID, userID int64) error {
 	// both two code are fine, 422 and 204
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusUnprocessableEntity {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("DeleteMembership: status %d, body %s",
-			resp.StatusCode, string(body))
+		return newAPIError("DeleteMembership", resp, body)
 	}
 
 	return nil
@@ -80,19 +53,18 @@ func DeleteMembership(apiEndpoint, token string, teamID, userID int64) error {
 // SaveMembership adds or updates xxa user's role in a Sysdig team.
 // PUT /platform/v1/teams/{teamId}/users/{userId}
 // https://app.sysdigcloud.com/apidocs/monitor?_product=SDC#tag/Teams/operation/saveTeamUserV1
-func SaveMembership(apiEndpoint, token string, teamID, userID int64, role string) (string, error) {
-	url := fmt.Sprintf("%s/platform/v1/teams/%d/users/%d", apiEndpoint, teamID, userID)
+func (c *SysdigClient) SaveMembership(ctx context.Context, teamID, userID int64, role string) (string, error) {
+	ctx, cancel := c.withTimeout(ctx, "SaveMembership")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/teams/%d/users/%d", c.endpoints.Platform, teamID, userID)
 	payload := map[string]string{"standardTeamRole": role}
-	body, _ := json.Marshal(payload)
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(body))
+	req, err := c.newRequest(ctx, "PUT", url, payload)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := c.do("SaveMembership", req)
 	if err != nil {
 		return "", err
 	}
@@ -106,5 +78,5 @@ func SaveMembership(apiEndpoint, token string, teamID, userID int64, role string
 	}
 	// Read response body for success path
 
-	return "", fmt.Errorf("SaveMembership: status %d, body %s", resp.StatusCode, string(respBody))
+	return "", newAPIError("SaveMembership", resp, respBody)
 }
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// apiRequestsTotal counts every HTTP attempt made against the Sysdig API.
+	apiRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sysdig_api_requests_total",
+			Help: "Number of HTTP requests sent to the Sysdig API, by operation and status code.",
+		},
+		[]string{"operation", "code"},
+	)
+
+	// apiRetriesTotal counts retried attempts, so we can alert on API instability.
+	apiRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sysdig_api_retries_total",
+			Help: "Number of Sysdig API calls that were retried, by operation and reason.",
+		},
+		[]string{"operation", "reason"},
+	)
+
+	// apiRateLimitWaitSeconds tracks how long calls wait on the client-side rate limiter.
+	apiRateLimitWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sysdig_api_rate_limit_wait_seconds",
+			Help:    "Time spent waiting on the client-side Sysdig API rate limiter, by operation and endpoint class.",
+			Buckets: []float64{0.001, 0.01, 0.1, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"operation", "class"},
+	)
+
+	// cacheRequestsTotal counts user and team lookups served by the cache.
+	cacheRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sysdig_cache_requests_total",
+			Help: "Number of Sysdig user and team lookups, by kind and result (hit or miss).",
+		},
+		[]string{"kind", "result"},
+	)
+
+	// cacheRefreshesTotal counts bulk refreshes of the user and team cache.
+	cacheRefreshesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sysdig_cache_refreshes_total",
+			Help: "Number of bulk Sysdig user and team cache refreshes, by kind and result.",
+		},
+		[]string{"kind", "result"},
+	)
+)
+
+// Register the Sysdig client metrics with the manager's metrics endpoint.
+func init() {
+	metrics.Registry.MustRegister(
+		apiRequestsTotal,
+		apiRetriesTotal,
+		apiRateLimitWaitSeconds,
+		cacheRequestsTotal,
+		cacheRefreshesTotal,
+	)
+}
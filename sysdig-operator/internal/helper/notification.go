@@ -0,0 +1,148 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// NotificationChannel is a notification channel from
+// /platform/v1/notification-channels. Channels with a TeamID are only
+// visible to that team.
+type NotificationChannel struct {
+	ID        int64                     `json:"id,omitempty"`
+	Version   int64                     `json:"version,omitempty"`
+	Type      string                    `json:"type"`
+	Name      string                    `json:"name"`
+	TeamID    int64                     `json:"teamId,omitempty"`
+	IsEnabled bool                      `json:"isEnabled"`
+	Config    NotificationChannelConfig `json:"config"`
+}
+
+// NotificationChannelConfig holds the type specific settings of a channel.
+// URL is a webhook URL and has to be treated as a secret.
+type NotificationChannelConfig struct {
+	EmailRecipients []string `json:"emailRecipients,omitempty"`
+	URL             string   `json:"url,omitempty"`
+	Channel         string   `json:"channel,omitempty"`
+}
+
+// Notification channel types accepted by Sysdig.
+const (
+	ChannelTypeEmail   = "EMAIL"
+	ChannelTypeSlack   = "SLACK"
+	ChannelTypeMSTeams = "MS_TEAMS"
+	ChannelTypeWebhook = "WEBHOOK"
+)
+
+// CreateNotificationChannel creates a channel and returns its ID.
+func (c *SysdigClient) CreateNotificationChannel(ctx context.Context, channel NotificationChannel) (int64, error) {
+	ctx, cancel := c.withTimeout(inTeam(ctx, channel.TeamID), "CreateNotificationChannel")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/notification-channels", c.endpoints.Platform)
+	channel.ID, channel.Version = 0, 0
+	req, err := c.newRequest(ctx, "POST", url, channel)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.do("CreateNotificationChannel", req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("reading CreateNotificationChannel response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return 0, newAPIError("CreateNotificationChannel", resp, body)
+	}
+
+	var created NotificationChannel
+	if err := json.Unmarshal(body, &created); err != nil {
+		return 0, fmt.Errorf("parsing CreateNotificationChannel response JSON: %w", err)
+	}
+	return created.ID, nil
+}
+
+// GetNotificationChannel fetches a channel by ID.
+func (c *SysdigClient) GetNotificationChannel(ctx context.Context, id int64) (*NotificationChannel, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetNotificationChannel")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/notification-channels/%d", c.endpoints.Platform, id)
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do("GetNotificationChannel", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading GetNotificationChannel response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("GetNotificationChannel", resp, body)
+	}
+
+	var channel NotificationChannel
+	if err := json.Unmarshal(body, &channel); err != nil {
+		return nil, fmt.Errorf("parsing GetNotificationChannel response JSON: %w", err)
+	}
+	return &channel, nil
+}
+
+// UpdateNotificationChannel replaces a channel. channel.Version must be the
+// version last read, or Sysdig rejects the update with a conflict.
+func (c *SysdigClient) UpdateNotificationChannel(ctx context.Context, channel *NotificationChannel) error {
+	ctx, cancel := c.withTimeout(inTeam(ctx, channel.TeamID), "UpdateNotificationChannel")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/notification-channels/%d", c.endpoints.Platform, channel.ID)
+	req, err := c.newRequest(ctx, "PUT", url, channel)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do("UpdateNotificationChannel", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("UpdateNotificationChannel", resp, body)
+	}
+	return nil
+}
+
+// DeleteNotificationChannel deletes a channel by ID.
+func (c *SysdigClient) DeleteNotificationChannel(ctx context.Context, id int64) error {
+	ctx, cancel := c.withTimeout(ctx, "DeleteNotificationChannel")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/notification-channels/%d", c.endpoints.Platform, id)
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do("DeleteNotificationChannel", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("DeleteNotificationChannel", resp, body)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// defaultPageSize is the number of items requested per page.
+// 200 is the largest page size the platform API accepts.
+const defaultPageSize = 200
+
+// Page is the pagination block returned alongside "data" by list endpoints.
+// Next and Prev hold the offset (or cursor) of the neighbouring pages.
+type Page struct {
+	Total int64  `json:"total"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// ListOptions controls how a list endpoint is walked.
+type ListOptions struct {
+	// Filter is passed through as the "filter" query parameter, e.g. "name:abc123-team".
+	Filter string
+	// PageSize is the number of items requested per call. Defaults to defaultPageSize.
+	PageSize int
+	// Limit caps the total number of items returned. Zero means no limit.
+	Limit int
+}
+
+// listResponse is the { "page": {...}, "data": [...] } wrapper used by the platform API.
+type listResponse[T any] struct {
+	Page Page `json:"page"`
+	Data []T  `json:"data"`
+}
+
+// paginate returns an iterator that walks every page of a list endpoint.
+// Iteration stops after the first error, which is yielded with a zero value.
+func paginate[T any](ctx context.Context, c *SysdigClient, op, endpoint string, opts ListOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		pageSize := opts.PageSize
+		if pageSize <= 0 {
+			pageSize = defaultPageSize
+		}
+
+		offset := "0"
+		seen := 0
+		for {
+			size := pageSize
+			if opts.Limit > 0 && opts.Limit-seen < size {
+				size = opts.Limit - seen
+			}
+
+			q := url.Values{}
+			q.Set("offset", offset)
+			q.Set("limit", strconv.Itoa(size))
+			if opts.Filter != "" {
+				q.Set("filter", opts.Filter)
+			}
+
+			page, err := fetchPage[T](ctx, c, op, endpoint+"?"+q.Encode())
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Data {
+				if !yield(item, nil) {
+					return
+				}
+				seen++
+				if opts.Limit > 0 && seen >= opts.Limit {
+					return
+				}
+			}
+
+			next, ok := nextOffset(page, offset, size)
+			if !ok {
+				return
+			}
+			offset = next
+		}
+	}
+}
+
+// fetchPage GETs a single page and decodes it, bounded by the operation timeout.
+func fetchPage[T any](ctx context.Context, c *SysdigClient, op, endpoint string) (*listResponse[T], error) {
+	ctx, cancel := c.withTimeout(ctx, op)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("building %s request: %w", op, err)
+	}
+
+	resp, err := c.do(op, req)
+	if err != nil {
+		return nil, fmt.Errorf("calling %s: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s body: %w", op, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(op, resp, body)
+	}
+
+	var page listResponse[T]
+	if len(body) == 0 {
+		return &page, nil
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("decoding %s JSON: %w", op, err)
+	}
+	return &page, nil
+}
+
+// nextOffset works out where the next page starts. It prefers the "next"
+// value from the response and falls back to offset arithmetic against
+// "total". It reports false when there are no more pages.
+func nextOffset[T any](page *listResponse[T], current string, size int) (string, bool) {
+	if len(page.Data) == 0 {
+		return "", false
+	}
+	if page.Page.Next != "" {
+		// Guard against an API that keeps pointing at the same page.
+		if page.Page.Next == current {
+			return "", false
+		}
+		return page.Page.Next, true
+	}
+
+	cur, err := strconv.ParseInt(current, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	next := cur + int64(len(page.Data))
+	if page.Page.Total > 0 {
+		return strconv.FormatInt(next, 10), next < page.Page.Total
+	}
+	// No page metadata: a short page means we reached the end.
+	return strconv.FormatInt(next, 10), len(page.Data) >= size
+}
+
+// collect drains an iterator into a slice, stopping at the first error.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	out := []T{}
+	for item, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
@@ -0,0 +1,87 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pagination", func() {
+	var (
+		server *httptest.Server
+		client *SysdigClient
+		users  []SysdigUser
+		calls  int
+	)
+
+	// serveUsers pages through users, optionally advertising the next offset.
+	serveUsers := func(withNext bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			end := min(offset+limit, len(users))
+			page := Page{Total: int64(len(users))}
+			if withNext && end < len(users) {
+				page.Next = strconv.Itoa(end)
+			}
+			_ = json.NewEncoder(w).Encode(listResponse[SysdigUser]{Page: page, Data: users[offset:end]})
+		}
+	}
+
+	BeforeEach(func() {
+		calls = 0
+		users = nil
+		for i := 1; i <= 5; i++ {
+			users = append(users, SysdigUser{ID: int64(i), Email: fmt.Sprintf("user%d@gov.bc.ca", i)})
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("follows page.next until the last page", func() {
+		server = httptest.NewServer(serveUsers(true))
+		client = NewSysdigClient(server.URL, "", "token")
+
+		got, err := collect(client.IterUsers(ctx, ListOptions{PageSize: 2}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(users))
+		Expect(calls).To(Equal(3))
+	})
+
+	It("falls back to page.total when next is missing", func() {
+		server = httptest.NewServer(serveUsers(false))
+		client = NewSysdigClient(server.URL, "", "token")
+
+		got, err := collect(client.IterUsers(ctx, ListOptions{PageSize: 2}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(users))
+	})
+
+	It("stops at the requested limit", func() {
+		server = httptest.NewServer(serveUsers(true))
+		client = NewSysdigClient(server.URL, "", "token")
+
+		got, err := collect(client.IterUsers(ctx, ListOptions{PageSize: 2, Limit: 3}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(users[:3]))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("yields the error of a failing page", func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		client = NewSysdigClient(server.URL, "", "token")
+
+		_, err := client.FetchUsers(ctx, "")
+		Expect(err).To(MatchError(ContainSubstring("FetchUsers: status 403")))
+	})
+})
@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TeamSettings are the UI settings and additional permissions of a team.
+type TeamSettings struct {
+	UI          UISettings
+	Permissions map[string]bool
+}
+
+// SettingsRequest is what a SysdigTeam asks for. Empty fields mean "use the
+// policy default".
+type SettingsRequest struct {
+	Module      string
+	Selection   string
+	Theme       string
+	Permissions map[string]bool
+}
+
+// ProductPolicy is the platform's say over the settings of one product team.
+type ProductPolicy struct {
+	// AllowedPermissions are the permissions tenants may turn on. Any
+	// permission may be turned off.
+	AllowedPermissions []string `yaml:"allowedPermissions"`
+	// DefaultPermissions apply to permissions the spec does not set.
+	DefaultPermissions map[string]bool `yaml:"defaultPermissions"`
+	// AllowedModules are the UI entry points tenants may pick.
+	AllowedModules []string `yaml:"allowedModules"`
+	// DefaultModule is the entry point when the spec sets none; empty means no entry point.
+	DefaultModule string `yaml:"defaultModule"`
+	DefaultTheme  string `yaml:"defaultTheme"`
+}
+
+// TeamPolicy holds the ProductPolicy of each product.
+type TeamPolicy struct {
+	Monitor ProductPolicy `yaml:"monitor"`
+	Secure  ProductPolicy `yaml:"secure"`
+}
+
+// themePattern matches the "#RRGGBB" colours Sysdig accepts as a theme.
+var themePattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// DefaultTeamPolicy returns the settings teams have always been created with,
+// and lets tenants toggle only the permissions that are on by default.
+func DefaultTeamPolicy() TeamPolicy {
+	return TeamPolicy{
+		Monitor: ProductPolicy{
+			AllowedPermissions: []string{"hasInfrastructureEvents", "hasAgentCli", "hasBeaconMetrics"},
+			DefaultPermissions: map[string]bool{
+				"hasSysdigCaptures":       false,
+				"hasInfrastructureEvents": true,
+				"hasAwsData":              false,
+				"hasRapidResponse":        false,
+				"hasAgentCli":             true,
+				"hasBeaconMetrics":        true,
+			},
+			AllowedModules: []string{"Explore", "Dashboards", "Events", "Alerts", "Overview"},
+			DefaultModule:  "Dashboards",
+			DefaultTheme:   "#73A1F7",
+		},
+		Secure: ProductPolicy{
+			AllowedPermissions: []string{"hasSysdigCaptures"},
+			DefaultPermissions: map[string]bool{
+				"hasSysdigCaptures":       true,
+				"hasInfrastructureEvents": false,
+				"hasAwsData":              false,
+				"hasRapidResponse":        false,
+				"hasAgentCli":             false,
+				"hasBeaconMetrics":        false,
+			},
+			AllowedModules: []string{"Explore", "Events", "Policies", "Compliance", "Insights"},
+			DefaultTheme:   "#73A1F7",
+		},
+	}
+}
+
+// LoadTeamPolicy reads a YAML policy file. Products or fields missing from
+// the file keep their DefaultTeamPolicy values.
+func LoadTeamPolicy(path string) (TeamPolicy, error) {
+	policy := DefaultTeamPolicy()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TeamPolicy{}, err
+	}
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return TeamPolicy{}, fmt.Errorf("parsing team policy %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// For returns the policy of a product, "monitor" or "secure".
+func (p TeamPolicy) For(product string) ProductPolicy {
+	if product == "secure" {
+		return p.Secure
+	}
+	return p.Monitor
+}
+
+// DefaultSettings returns the settings of a team whose spec asks for nothing.
+func (p ProductPolicy) DefaultSettings() TeamSettings {
+	settings, _ := p.Clamp(SettingsRequest{})
+	return settings
+}
+
+// Clamp applies the policy to a request. It returns the effective settings
+// and a description of every requested value the policy overrode.
+func (p ProductPolicy) Clamp(req SettingsRequest) (TeamSettings, []string) {
+	var clamped []string
+
+	perms := maps.Clone(p.DefaultPermissions)
+	if perms == nil {
+		perms = map[string]bool{}
+	}
+	names := slices.Collect(maps.Keys(req.Permissions))
+	sort.Strings(names)
+	for _, name := range names {
+		on := req.Permissions[name]
+		if on && !slices.Contains(p.AllowedPermissions, name) {
+			clamped = append(clamped, fmt.Sprintf("permission %s may not be turned on", name))
+			continue
+		}
+		perms[name] = on
+	}
+
+	ui := UISettings{Theme: p.DefaultTheme}
+	if req.Theme != "" {
+		if themePattern.MatchString(req.Theme) {
+			ui.Theme = req.Theme
+		} else {
+			clamped = append(clamped, fmt.Sprintf("theme %q is not a #RRGGBB colour", req.Theme))
+		}
+	}
+
+	module, selection := p.DefaultModule, ""
+	if req.Module != "" {
+		if slices.Contains(p.AllowedModules, req.Module) {
+			module, selection = req.Module, req.Selection
+		} else {
+			clamped = append(clamped, fmt.Sprintf("entry point %s is not allowed", req.Module))
+		}
+	}
+	if module != "" {
+		ui.EntryPoint = &EntryPoint{Module: module}
+		if selection != "" {
+			ui.EntryPoint.Selection = &selection
+		}
+	}
+
+	return TeamSettings{UI: ui, Permissions: perms}, clamped
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Team policy", func() {
+	It("creates teams with the historical settings by default", func() {
+		settings := DefaultTeamPolicy().Monitor.DefaultSettings()
+		Expect(settings.UI.Theme).To(Equal("#73A1F7"))
+		Expect(settings.UI.EntryPoint.Module).To(Equal("Dashboards"))
+		Expect(settings.Permissions).To(HaveKeyWithValue("hasAgentCli", true))
+		Expect(settings.Permissions).To(HaveKeyWithValue("hasAwsData", false))
+
+		Expect(DefaultTeamPolicy().Secure.DefaultSettings().UI.EntryPoint).To(BeNil())
+	})
+
+	It("lets tenants turn permissions off but only allowed ones on", func() {
+		settings, clamped := DefaultTeamPolicy().Monitor.Clamp(SettingsRequest{
+			Permissions: map[string]bool{"hasAgentCli": false, "hasRapidResponse": true},
+		})
+		Expect(settings.Permissions).To(HaveKeyWithValue("hasAgentCli", false))
+		Expect(settings.Permissions).To(HaveKeyWithValue("hasRapidResponse", false))
+		Expect(clamped).To(ConsistOf("permission hasRapidResponse may not be turned on"))
+	})
+
+	It("falls back to the defaults for modules and themes it does not allow", func() {
+		settings, clamped := DefaultTeamPolicy().Monitor.Clamp(SettingsRequest{
+			Module: "Settings", Theme: "red",
+		})
+		Expect(settings.UI.EntryPoint.Module).To(Equal("Dashboards"))
+		Expect(settings.UI.Theme).To(Equal("#73A1F7"))
+		Expect(clamped).To(HaveLen(2))
+
+		settings, clamped = DefaultTeamPolicy().Monitor.Clamp(SettingsRequest{
+			Module: "Explore", Selection: "kubernetes", Theme: "#00aa00",
+		})
+		Expect(clamped).To(BeEmpty())
+		Expect(*settings.UI.EntryPoint.Selection).To(Equal("kubernetes"))
+		Expect(settings.UI.Theme).To(Equal("#00aa00"))
+	})
+
+	It("loads a policy file over the defaults", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "policy.yaml")
+		Expect(os.WriteFile(path, []byte("monitor:\n  allowedPermissions: [hasRapidResponse]\n"), 0o600)).To(Succeed())
+
+		policy, err := LoadTeamPolicy(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Monitor.AllowedPermissions).To(Equal([]string{"hasRapidResponse"}))
+		Expect(policy.Monitor.DefaultModule).To(Equal("Dashboards"))
+		Expect(policy.Secure).To(Equal(DefaultTeamPolicy().Secure))
+
+		Expect(os.WriteFile(path, []byte("monitor:\n  allowedPermission: [x]\n"), 0o600)).To(Succeed())
+		_, err = LoadTeamPolicy(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PromQLNamespaceLabel is the namespace label of Sysdig PromQL series.
+const PromQLNamespaceLabel = "kube_namespace_name"
+
+// promqlKeywords are identifiers of a PromQL expression that are not metric
+// names: binary and set operators, modifiers and aggregation operators,
+// which may be followed by a by or without clause instead of "(".
+var promqlKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "bool": true, "offset": true,
+	"inf": true, "nan": true, "atan2": true,
+	"sum": true, "min": true, "max": true, "avg": true, "group": true,
+	"stddev": true, "stdvar": true, "count": true, "count_values": true,
+	"bottomk": true, "topk": true, "quantile": true, "limitk": true, "limit_ratio": true,
+}
+
+// promqlLabelLists are keywords followed by a parenthesised list of labels.
+var promqlLabelLists = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true,
+}
+
+// CheckPromQLScope checks that every series selector of query is limited to
+// namespaces by a kube_namespace_name matcher, e.g.
+//
+//	sum(kube_pod_container_status_restarts_total{kube_namespace_name=~"abc123-dev|abc123-prod"}) > 5
+//
+// The matcher must use = with one of namespaces, or =~ with namespaces
+// separated by |. Other matchers on the label are not checked.
+func CheckPromQLScope(query string, namespaces []string) error {
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			end := closingQuote(query, i)
+			if end < 0 {
+				return fmt.Errorf("unterminated string in query")
+			}
+			i = end + 1
+		case c == '{':
+			end := closingBrace(query, i)
+			if end < 0 {
+				return fmt.Errorf("unterminated selector in query")
+			}
+			if err := checkNamespaceMatchers(query[i+1:end], namespaces); err != nil {
+				return err
+			}
+			i = end + 1
+		case isDigit(c):
+			// Numbers and durations such as 5m or 1e3.
+			for i < len(query) && (isIdentChar(query[i]) || query[i] == '.') {
+				i++
+			}
+		case isIdentStart(c):
+			j := i
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			name := query[i:j]
+			next := j
+			for next < len(query) && (query[next] == ' ' || query[next] == '\t' || query[next] == '\n') {
+				next++
+			}
+			switch {
+			case promqlLabelLists[name]:
+				if next < len(query) && query[next] == '(' {
+					end := strings.IndexByte(query[next:], ')')
+					if end < 0 {
+						return fmt.Errorf("unterminated label list after %s", name)
+					}
+					j = next + end + 1
+				}
+			case promqlKeywords[name]:
+			case next < len(query) && (query[next] == '(' || query[next] == '{'):
+				// A function call, or a selector checked with its braces.
+			default:
+				return fmt.Errorf("selector %s has no %s matcher", name, PromQLNamespaceLabel)
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+	return nil
+}
+
+// checkNamespaceMatchers checks the matchers between the braces of one
+// selector.
+func checkNamespaceMatchers(matchers string, namespaces []string) error {
+	scoped := false
+	for _, m := range splitMatchers(matchers) {
+		label, rest, ok := strings.Cut(m, "=")
+		op := "="
+		if ok && strings.HasPrefix(rest, "~") {
+			op, rest = "=~", rest[1:]
+		}
+		label = strings.TrimSpace(label)
+		if !ok || label != PromQLNamespaceLabel {
+			continue
+		}
+		value := strings.TrimSpace(rest)
+		if len(value) < 2 || !strings.ContainsRune(`"'`+"`", rune(value[0])) || value[len(value)-1] != value[0] {
+			return fmt.Errorf("%s matcher %q needs a quoted value", PromQLNamespaceLabel, m)
+		}
+		value = value[1 : len(value)-1]
+		values := []string{value}
+		if op == "=~" {
+			values = strings.Split(value, "|")
+		}
+		for _, v := range values {
+			if !slices.Contains(namespaces, v) {
+				return fmt.Errorf("%s matcher %q allows %q, which is not a namespace of the team", PromQLNamespaceLabel, m, v)
+			}
+		}
+		scoped = true
+	}
+	if !scoped {
+		return fmt.Errorf("selector {%s} has no %s matcher", matchers, PromQLNamespaceLabel)
+	}
+	return nil
+}
+
+// splitMatchers splits the matchers of a selector on commas outside quotes.
+func splitMatchers(matchers string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(matchers); i++ {
+		switch matchers[i] {
+		case '"', '\'', '`':
+			if end := closingQuote(matchers, i); end >= 0 {
+				i = end
+			}
+		case ',':
+			out = append(out, matchers[start:i])
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(matchers[start:]); rest != "" {
+		out = append(out, rest)
+	}
+	return out
+}
+
+// closingQuote returns the index of the quote closing the string at s[i].
+func closingQuote(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case s[j] == '\\' && q != '`':
+			j++
+		case s[j] == q:
+			return j
+		}
+	}
+	return -1
+}
+
+// closingBrace returns the index of the brace closing the selector at s[i].
+func closingBrace(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '"', '\'', '`':
+			if j = closingQuote(s, j); j < 0 {
+				return -1
+			}
+		case '}':
+			return j
+		}
+	}
+	return -1
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool { return isIdentStart(c) || isDigit(c) }
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PromQL scope", func() {
+	namespaces := []string{"abc123-dev", "abc123-prod"}
+
+	It("accepts selectors limited to the team namespaces", func() {
+		for _, query := range []string{
+			`sum(kube_pod_container_status_restarts_total{kube_namespace_name="abc123-dev"}) > 5`,
+			`sum by (kube_pod_name) (rate(container_cpu_usage_seconds_total{kube_namespace_name=~"abc123-dev|abc123-prod", container!=""}[5m])) > 0.9`,
+			`max(up{kube_namespace_name='abc123-prod'}) offset 5m < bool 1`,
+			`label_replace(kube_pod_info{kube_namespace_name="abc123-dev",created_by_kind="Job"}, "dst", "$1", "src", "(.*)")`,
+			`a{kube_namespace_name="abc123-dev"} / on (kube_pod_name) group_left(node) b{kube_namespace_name="abc123-prod"}`,
+		} {
+			Expect(CheckPromQLScope(query, namespaces)).To(Succeed(), query)
+		}
+	})
+
+	It("rejects selectors without a namespace matcher", func() {
+		Expect(CheckPromQLScope(`sum(kube_pod_container_status_restarts_total) > 5`, namespaces)).
+			To(MatchError(ContainSubstring("kube_pod_container_status_restarts_total has no kube_namespace_name matcher")))
+		Expect(CheckPromQLScope(`up{job="x"} + up{kube_namespace_name="abc123-dev"}`, namespaces)).
+			To(MatchError(ContainSubstring(`{job="x"} has no`)))
+		Expect(CheckPromQLScope(`up{kube_namespace_name!="abc123-dev"}`, namespaces)).
+			To(MatchError(ContainSubstring("has no")))
+	})
+
+	It("rejects namespaces of other teams", func() {
+		Expect(CheckPromQLScope(`up{kube_namespace_name="def456-prod"}`, namespaces)).
+			To(MatchError(ContainSubstring(`"def456-prod"`)))
+		Expect(CheckPromQLScope(`up{kube_namespace_name=~"abc123-.*"}`, namespaces)).
+			To(MatchError(ContainSubstring(`"abc123-.*"`)))
+		Expect(CheckPromQLScope(`up{kube_namespace_name=~"abc123-dev|.+"}`, namespaces)).
+			To(MatchError(ContainSubstring(`".+"`)))
+	})
+
+	It("does not mistake strings for selectors", func() {
+		Expect(CheckPromQLScope(`up{kube_namespace_name="abc123-dev", path="}{"}`, namespaces)).To(Succeed())
+		Expect(CheckPromQLScope(`up{kube_namespace_name="abc123-dev`, namespaces)).
+			To(MatchError(ContainSubstring("unterminated")))
+	})
+})
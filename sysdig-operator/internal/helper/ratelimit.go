@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig sets the client-side request budget for the whole tenant.
+// Reads (GET) and writes (everything else) are limited separately, so a
+// burst of membership writes cannot starve the lookups of other reconciles.
+type RateLimitConfig struct {
+	ReadsPerSecond  float64
+	ReadBurst       int
+	WritesPerSecond float64
+	WriteBurst      int
+}
+
+// DefaultRateLimitConfig returns the limits used by NewSysdigClient.
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		ReadsPerSecond:  10,
+		ReadBurst:       20,
+		WritesPerSecond: 5,
+		WriteBurst:      10,
+	}
+}
+
+// rateLimiter holds one token bucket per endpoint class.
+type rateLimiter struct {
+	reads  *rate.Limiter
+	writes *rate.Limiter
+}
+
+// newRateLimiter builds the token buckets. A non-positive rate disables
+// limiting for that class.
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		reads:  newLimiter(cfg.ReadsPerSecond, cfg.ReadBurst),
+		writes: newLimiter(cfg.WritesPerSecond, cfg.WriteBurst),
+	}
+}
+
+func newLimiter(perSecond float64, burst int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), max(burst, 1))
+}
+
+// wait blocks until the bucket for the request's method has a token.
+func (l *rateLimiter) wait(ctx context.Context, op, method string) error {
+	limiter, class := l.writes, "write"
+	if method == http.MethodGet || method == http.MethodHead {
+		limiter, class = l.reads, "read"
+	}
+
+	start := time.Now()
+	if err := limiter.Wait(ctx); err != nil {
+		return err
+	}
+	apiRateLimitWaitSeconds.WithLabelValues(op, class).Observe(time.Since(start).Seconds())
+	return nil
+}
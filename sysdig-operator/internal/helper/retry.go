@@ -0,0 +1,136 @@
+package helpers
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how one Sysdig operation is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value of 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry. It doubles on every attempt.
+	BaseDelay time.Duration
+	// MaxDelay caps both the backoff and any Retry-After sent by the API.
+	MaxDelay time.Duration
+	// RetryNonIdempotent allows a POST to be retried after a 5xx or a network
+	// error. Only set it for operations that are safe to repeat.
+	RetryNonIdempotent bool
+}
+
+// RetryConfig holds the default retry policy and per-operation overrides,
+// keyed by operation name (e.g. "FetchUsers", "CreateTeam").
+type RetryConfig struct {
+	Default    RetryPolicy
+	Operations map[string]RetryPolicy
+}
+
+// DefaultRetryConfig returns the retry settings used by NewSysdigClient.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Default: RetryPolicy{
+			MaxAttempts: 4,
+			BaseDelay:   500 * time.Millisecond,
+			MaxDelay:    30 * time.Second,
+		},
+		Operations: map[string]RetryPolicy{
+			// Sysdig rejects a duplicate email with 409, so a repeated create cannot
+			// produce a second user, and CreateUser resolves the 409 to the
+			// existing one.
+			"CreateUser": {
+				MaxAttempts:        4,
+				BaseDelay:          500 * time.Millisecond,
+				MaxDelay:           30 * time.Second,
+				RetryNonIdempotent: true,
+			},
+			// Dashboards are created once per team and are not deduplicated by the API.
+			"CreateDashboard": {
+				MaxAttempts: 2,
+				BaseDelay:   time.Second,
+				MaxDelay:    30 * time.Second,
+			},
+		},
+	}
+}
+
+// policyFor returns the retry policy for the given operation.
+func (rc RetryConfig) policyFor(op string) RetryPolicy {
+	if p, ok := rc.Operations[op]; ok {
+		return p
+	}
+	return rc.Default
+}
+
+// isIdempotent reports whether the HTTP method can be repeated safely.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// retryReason decides whether an attempt should be retried. It returns the
+// reason used in metrics, or "" when the result is final.
+func retryReason(p RetryPolicy, method string, resp *http.Response, err error) string {
+	safe := isIdempotent(method) || p.RetryNonIdempotent
+	switch {
+	case err != nil:
+		if safe {
+			return "network"
+		}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// A throttled request was never processed, so any verb may be repeated.
+		return "throttled"
+	case resp.StatusCode >= 500:
+		if safe {
+			return "server_error"
+		}
+	}
+	return ""
+}
+
+// backoff returns the delay before the given retry (1-based), using
+// exponential backoff with equal jitter.
+func backoff(p RetryPolicy, retry int) time.Duration {
+	d := p.BaseDelay << (retry - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// drain discards and closes a response body so the connection can be reused.
+func drain(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
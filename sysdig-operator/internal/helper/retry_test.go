@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Retries", func() {
+	var (
+		server   *httptest.Server
+		client   *SysdigClient
+		statuses []int
+		calls    int
+	)
+
+	fastRetries := RetryConfig{
+		Default: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		Operations: map[string]RetryPolicy{
+			"CreateUser": {MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, RetryNonIdempotent: true},
+		},
+	}
+
+	BeforeEach(func() {
+		calls = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := statuses[min(calls, len(statuses)-1)]
+			calls++
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(status)
+			if status == http.StatusCreated {
+				_, _ = w.Write([]byte(`{"id": 42}`))
+			}
+		}))
+		client = NewSysdigClient(server.URL, server.URL, "token").WithRetryConfig(fastRetries)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("retries throttled reads and counts the retries", func() {
+		statuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusOK}
+		before := testutil.ToFloat64(apiRetriesTotal.WithLabelValues("FetchTeams", "throttled"))
+
+		_, err := client.FetchTeams(ctx, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(calls).To(Equal(3))
+		Expect(testutil.ToFloat64(apiRetriesTotal.WithLabelValues("FetchTeams", "throttled"))).To(Equal(before + 1))
+	})
+
+	It("gives up after the attempt budget", func() {
+		statuses = []int{http.StatusServiceUnavailable}
+
+		_, err := client.FetchTeamMemberships(ctx, 7)
+		Expect(err).To(MatchError(ContainSubstring("status 503")))
+		Expect(calls).To(Equal(3))
+	})
+
+	It("does not retry a non-idempotent POST after a server error", func() {
+		statuses = []int{http.StatusServiceUnavailable, http.StatusCreated}
+
+		_, err := client.CreateTeam(ctx, "abc123-team", "", "monitor", TeamScopes(BuildFilterExpression([]string{"abc123-tools"})),
+			DefaultTeamPolicy().Monitor.DefaultSettings())
+		Expect(err).To(HaveOccurred())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("retries POSTs that are marked safe and replays the body", func() {
+		statuses = []int{http.StatusServiceUnavailable, http.StatusCreated}
+
+		id, err := client.CreateUser(ctx, "jane.doe@gov.bc.ca", "ROLE_TEAM_READ")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal(int64(42)))
+		Expect(calls).To(Equal(2))
+	})
+
+	It("stops waiting to retry once the context is cancelled", func() {
+		statuses = []int{http.StatusServiceUnavailable}
+		client.WithRetryConfig(RetryConfig{Default: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute}})
+		cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := client.FetchTeamMemberships(cancelled, 7)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("applies the configured per-operation timeout", func() {
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer slow.Close()
+		client = NewSysdigClient(slow.URL, slow.URL, "token").
+			WithRetryConfig(RetryConfig{Default: RetryPolicy{MaxAttempts: 1}}).
+			WithTimeouts(TimeoutConfig{Operations: map[string]time.Duration{"DeleteTeam": 20 * time.Millisecond}})
+
+		err := client.DeleteTeam(ctx, 7)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("parses operation timeouts from configuration", func() {
+		timeouts, err := ParseOperationTimeouts("CreateDashboard=30s, FetchUsers=2m")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timeouts).To(Equal(map[string]time.Duration{"CreateDashboard": 30 * time.Second, "FetchUsers": 2 * time.Minute}))
+
+		_, err = ParseOperationTimeouts("CreateDashboard")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("parses Retry-After in seconds", func() {
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+		d, ok := retryAfter(resp)
+		Expect(ok).To(BeTrue())
+		Expect(d).To(Equal(3 * time.Second))
+	})
+})
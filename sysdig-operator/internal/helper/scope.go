@@ -0,0 +1,272 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ScopeClause is one extra condition of a team scope, e.g.
+// kubernetes.cluster.name in ("silver"). Clauses are ANDed with the
+// namespace clause, so they can only narrow what a team sees.
+type ScopeClause struct {
+	Label    string
+	Operator string
+	Values   []string
+}
+
+// scopeOperators maps the operators accepted in a ScopeClause onto the
+// Sysdig scope syntax, and whether they take a list of values.
+var scopeOperators = map[string]struct {
+	syntax string
+	list   bool
+}{
+	"in":          {syntax: "in", list: true},
+	"notIn":       {syntax: "not in", list: true},
+	"equals":      {syntax: "=", list: false},
+	"notEquals":   {syntax: "!=", list: false},
+	"contains":    {syntax: "contains", list: false},
+	"notContains": {syntax: "does not contain", list: false},
+	"startsWith":  {syntax: "starts with", list: false},
+}
+
+// scopeLabelPattern is what a label may look like. Labels are written into
+// the scope unquoted, so anything else could change the expression.
+var scopeLabelPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// DefaultScopeAllowlist lists the labels tenants may narrow their scope by
+// unless the platform configures its own list.
+var DefaultScopeAllowlist = ScopeAllowlist{
+	"kubernetes.cluster.name",
+	"kubernetes.workload.name",
+	"kubernetes.workload.type",
+	"kubernetes.deployment.name",
+	"kubernetes.statefulSet.name",
+	"kubernetes.daemonSet.name",
+	"kubernetes.pod.label.*",
+}
+
+// ScopeAllowlist is the set of labels tenants may use in scope clauses.
+// An entry ending in ".*" allows every label with that prefix.
+type ScopeAllowlist []string
+
+// ParseScopeAllowlist parses a comma-separated list of labels.
+func ParseScopeAllowlist(s string) ScopeAllowlist {
+	var out ScopeAllowlist
+	for _, label := range strings.Split(s, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			out = append(out, label)
+		}
+	}
+	return out
+}
+
+// Allows reports whether label may be used in a scope clause.
+func (a ScopeAllowlist) Allows(label string) bool {
+	for _, allowed := range a {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(label, prefix) && len(label) > len(prefix) {
+				return true
+			}
+		} else if label == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a clause against the allowlist and the scope syntax.
+func (a ScopeAllowlist) Validate(clause ScopeClause) error {
+	if !scopeLabelPattern.MatchString(clause.Label) {
+		return fmt.Errorf("label %q may only contain letters, digits and _./-", clause.Label)
+	}
+	if !a.Allows(clause.Label) {
+		return fmt.Errorf("label %q is not allowed in team scopes", clause.Label)
+	}
+	op, ok := scopeOperators[clause.Operator]
+	if !ok {
+		return fmt.Errorf("unknown scope operator %q for label %s", clause.Operator, clause.Label)
+	}
+	switch {
+	case len(clause.Values) == 0:
+		return fmt.Errorf("operator %s on label %s needs a value", clause.Operator, clause.Label)
+	case !op.list && len(clause.Values) > 1:
+		return fmt.Errorf("operator %s on label %s takes exactly one value", clause.Operator, clause.Label)
+	}
+	for _, v := range clause.Values {
+		if strings.ContainsAny(v, "\"\\\n") {
+			return fmt.Errorf("value %q for label %s contains a quote, backslash or newline", v, clause.Label)
+		}
+	}
+	return nil
+}
+
+// expression renders a validated clause in the Sysdig scope syntax.
+func (c ScopeClause) expression() string {
+	op := scopeOperators[c.Operator]
+	quoted := make([]string, len(c.Values))
+	for i, v := range c.Values {
+		quoted[i] = fmt.Sprintf("\"%s\"", v)
+	}
+	if op.list {
+		return fmt.Sprintf("%s %s (%s)", c.Label, op.syntax, strings.Join(quoted, ","))
+	}
+	return fmt.Sprintf("%s %s %s", c.Label, op.syntax, quoted[0])
+}
+
+// BuildScopeExpression ANDs the namespace clause with the given clauses.
+// The clauses must have been checked with ScopeAllowlist.Validate.
+func BuildScopeExpression(namespaces []string, clauses []ScopeClause) string {
+	parts := []string{BuildFilterExpression(namespaces)}
+	for _, c := range clauses {
+		parts = append(parts, c.expression())
+	}
+	return strings.Join(parts, " and ")
+}
+
+// clusterLabel and namespaceLabel are the labels the operator manages in an
+// agent scope expression.
+const (
+	clusterLabel   = "kubernetes.cluster.name"
+	namespaceLabel = "kubernetes.namespace.name"
+)
+
+// ParsedScope is an agent scope expression split into the clauses the
+// operator merges across clusters and everything else.
+type ParsedScope struct {
+	Clusters   []string
+	Namespaces []string
+	// Rest holds the remaining clauses, verbatim.
+	Rest []string
+}
+
+// ParseScopeExpression splits an expression built by this package. Clauses
+// it does not recognise end up in Rest.
+func ParseScopeExpression(expr string) ParsedScope {
+	var parsed ParsedScope
+	for _, clause := range splitAnd(expr) {
+		if values, ok := parseInClause(clause, clusterLabel); ok {
+			parsed.Clusters = values
+		} else if values, ok := parseInClause(clause, namespaceLabel); ok {
+			parsed.Namespaces = values
+		} else {
+			parsed.Rest = append(parsed.Rest, clause)
+		}
+	}
+	return parsed
+}
+
+// MergeScopeExpression returns the agent scope for one operator instance.
+// Without a cluster name it is BuildScopeExpression. With one, the cluster
+// is added to the clusters already in existing, so instances on several
+// clusters build up a union instead of overwriting each other. Sysdig
+// scopes are AND-only, so the union is a single clause per label:
+//
+//	kubernetes.cluster.name in ("gold","silver") and kubernetes.namespace.name in (...)
+//
+// While more than one cluster shares the team, namespaces are merged too.
+// previous are the namespaces this instance wrote last time; they are
+// dropped first, so a namespace removed here leaves the scope and other
+// instances add back the ones they still need. Clusters and namespaces are
+// sorted, so every instance computes the same expression.
+func MergeScopeExpression(existing, cluster string, previous, namespaces []string, clauses []ScopeClause) string {
+	if cluster == "" {
+		return BuildScopeExpression(namespaces, clauses)
+	}
+
+	parsed := ParseScopeExpression(existing)
+	clusters := union(parsed.Clusters, []string{cluster})
+	sort.Strings(clusters)
+	if len(clusters) > 1 {
+		namespaces = union(without(parsed.Namespaces, previous), namespaces)
+		sort.Strings(namespaces)
+	}
+	return inClause(clusterLabel, clusters) + " and " + BuildScopeExpression(namespaces, clauses)
+}
+
+// RemoveClusterFromScope drops cluster, and the namespaces it wrote last
+// (previous), from an agent scope expression. It reports whether any other
+// cluster still shares the team. The namespaces are kept when nothing else
+// would be left; the other clusters fix them up on their next sync.
+func RemoveClusterFromScope(existing, cluster string, previous []string) (string, bool) {
+	parsed := ParseScopeExpression(existing)
+	clusters := without(parsed.Clusters, []string{cluster})
+	if len(clusters) == 0 {
+		return existing, false
+	}
+
+	parts := []string{inClause(clusterLabel, clusters)}
+	namespaces := without(parsed.Namespaces, previous)
+	if len(namespaces) == 0 {
+		namespaces = parsed.Namespaces
+	}
+	if len(namespaces) > 0 {
+		parts = append(parts, inClause(namespaceLabel, namespaces))
+	}
+	parts = append(parts, parsed.Rest...)
+	return strings.Join(parts, " and "), true
+}
+
+func inClause(label string, values []string) string {
+	return ScopeClause{Label: label, Operator: "in", Values: values}.expression()
+}
+
+// parseInClause parses `<label> in ("a","b")`.
+func parseInClause(clause, label string) ([]string, bool) {
+	inner, ok := strings.CutPrefix(clause, label+" in (")
+	if !ok {
+		return nil, false
+	}
+	inner, ok = strings.CutSuffix(inner, ")")
+	if !ok || len(inner) < 2 || inner[0] != '"' || inner[len(inner)-1] != '"' {
+		return nil, false
+	}
+	// Values never contain quotes, so `","` only appears between values.
+	return strings.Split(inner[1:len(inner)-1], `","`), true
+}
+
+// splitAnd splits an expression on " and " outside quoted values.
+func splitAnd(expr string) []string {
+	var parts []string
+	inQuotes, start := false, 0
+	for i := 0; i < len(expr); i++ {
+		switch {
+		case expr[i] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(expr[i:], " and "):
+			parts = append(parts, expr[start:i])
+			start = i + len(" and ")
+			i = start - 1
+		}
+	}
+	if rest := strings.TrimSpace(expr[start:]); rest != "" {
+		parts = append(parts, rest)
+	}
+	return parts
+}
+
+// union returns a followed by the values of b it does not already contain.
+func union(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+	for _, v := range append(append([]string{}, a...), b...) {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// without returns the values of a that are not in b.
+func without(a, b []string) []string {
+	out := make([]string, 0, len(a))
+	for _, v := range a {
+		if !slices.Contains(b, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
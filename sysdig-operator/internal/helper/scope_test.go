@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scopes", func() {
+	It("ANDs the clauses with the namespace clause", func() {
+		expr := BuildScopeExpression([]string{"abc123-tools", "abc123-prod"}, []ScopeClause{
+			{Label: "kubernetes.cluster.name", Operator: "in", Values: []string{"silver", "gold"}},
+			{Label: "kubernetes.pod.label.app", Operator: "notEquals", Values: []string{"debug"}},
+		})
+		Expect(expr).To(Equal(`kubernetes.namespace.name in ("abc123-tools","abc123-prod")` +
+			` and kubernetes.cluster.name in ("silver","gold")` +
+			` and kubernetes.pod.label.app != "debug"`))
+	})
+
+	It("only allows listed labels and prefixes", func() {
+		allowlist := ParseScopeAllowlist("kubernetes.cluster.name, kubernetes.pod.label.*")
+		Expect(allowlist.Allows("kubernetes.cluster.name")).To(BeTrue())
+		Expect(allowlist.Allows("kubernetes.pod.label.app")).To(BeTrue())
+		Expect(allowlist.Allows("kubernetes.pod.label.")).To(BeFalse())
+		Expect(allowlist.Allows("kubernetes.namespace.name")).To(BeFalse())
+	})
+
+	It("rejects clauses that could break out of the expression", func() {
+		Expect(DefaultScopeAllowlist.Validate(ScopeClause{
+			Label: "kubernetes.cluster.name", Operator: "equals", Values: []string{`silver" or "a" = "a`},
+		})).To(MatchError(ContainSubstring("quote")))
+		Expect(DefaultScopeAllowlist.Validate(ScopeClause{
+			Label: "kubernetes.cluster.name", Operator: "equals", Values: []string{"silver", "gold"},
+		})).To(MatchError(ContainSubstring("exactly one value")))
+		Expect(DefaultScopeAllowlist.Validate(ScopeClause{
+			Label: "kubernetes.cluster.name", Operator: "or", Values: []string{"silver"},
+		})).To(MatchError(ContainSubstring("unknown scope operator")))
+		Expect(DefaultScopeAllowlist.Validate(ScopeClause{
+			Label:    `kubernetes.pod.label.app = "x" or kubernetes.namespace.name`,
+			Operator: "in", Values: []string{"other-ns"},
+		})).To(MatchError(ContainSubstring("may only contain")))
+	})
+
+	It("merges the cluster clauses of several operators into a union", func() {
+		silverNamespaces := []string{"abc123-tools", "abc123-prod"}
+		silver := MergeScopeExpression("", "silver", nil, silverNamespaces, nil)
+		Expect(silver).To(Equal(`kubernetes.cluster.name in ("silver")` +
+			` and kubernetes.namespace.name in ("abc123-tools","abc123-prod")`))
+
+		goldNamespaces := []string{"abc123-tools", "abc123-dev"}
+		both := MergeScopeExpression(silver, "gold", nil, goldNamespaces, nil)
+		Expect(both).To(Equal(`kubernetes.cluster.name in ("gold","silver")` +
+			` and kubernetes.namespace.name in ("abc123-dev","abc123-prod","abc123-tools")`))
+		Expect(both).NotTo(ContainSubstring(" or "))
+		Expect(MergeScopeExpression(both, "silver", silverNamespaces, silverNamespaces, nil)).To(Equal(both))
+
+		remaining, shared := RemoveClusterFromScope(both, "gold", goldNamespaces)
+		Expect(shared).To(BeTrue())
+		Expect(remaining).To(Equal(`kubernetes.cluster.name in ("silver")` +
+			` and kubernetes.namespace.name in ("abc123-prod")`))
+		_, shared = RemoveClusterFromScope(remaining, "silver", silverNamespaces)
+		Expect(shared).To(BeFalse())
+	})
+
+	It("drops the namespaces a cluster no longer has and lets the others add theirs back", func() {
+		both := `kubernetes.cluster.name in ("gold","silver") and kubernetes.namespace.name in ("a","b","c")`
+
+		narrowed := MergeScopeExpression(both, "silver", []string{"a", "b", "c"}, []string{"a"}, nil)
+		Expect(narrowed).To(Equal(`kubernetes.cluster.name in ("gold","silver") and kubernetes.namespace.name in ("a")`))
+
+		Expect(MergeScopeExpression(narrowed, "gold", []string{"a", "b"}, []string{"a", "b"}, nil)).To(Equal(
+			`kubernetes.cluster.name in ("gold","silver") and kubernetes.namespace.name in ("a","b")`))
+	})
+
+	It("keeps the namespaces when the removed cluster wrote all of them", func() {
+		remaining, shared := RemoveClusterFromScope(
+			`kubernetes.cluster.name in ("gold","silver") and kubernetes.namespace.name in ("a")`, "gold", []string{"a"})
+		Expect(shared).To(BeTrue())
+		Expect(remaining).To(Equal(`kubernetes.cluster.name in ("silver") and kubernetes.namespace.name in ("a")`))
+	})
+
+	It("keeps unrecognised clauses and quoted values intact", func() {
+		parsed := ParseScopeExpression(`kubernetes.namespace.name in ("a and b") and kubernetes.pod.label.app = "x"`)
+		Expect(parsed.Namespaces).To(Equal([]string{"a and b"}))
+		Expect(parsed.Rest).To(Equal([]string{`kubernetes.pod.label.app = "x"`}))
+	})
+})
@@ -0,0 +1,20 @@
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// These tests run the Sysdig client against an httptest server, so they
+// need no cluster or Sysdig tenant.
+
+var ctx = context.Background()
+
+func TestHelpers(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Helper Suite")
+}
@@ -1,13 +1,13 @@
 package helpers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"strings"
-	"time"
 )
 
 // SysdigTeam represents a team object in Sysdig
@@ -73,95 +73,33 @@ func BuildFilterExpression(namespaces []string) string {
 	return fmt.Sprintf("kubernetes.namespace.name in (%s)", strings.Join(quoted, ","))
 }
 
+// IterTeams streams teams from the Sysdig API, walking every page.
+func (c *SysdigClient) IterTeams(ctx context.Context, opts ListOptions) iter.Seq2[SysdigTeam, error] {
+	endpoint := fmt.Sprintf("%s/platform/v1/teams", c.endpoints.Platform)
+	return paginate[SysdigTeam](ctx, c, "FetchTeams", endpoint, opts)
+}
+
 // FetchTeams fetches teams from Sysdig API, optionally filtered by name
-func FetchTeams(apiEndpoint, token, filterName string) ([]SysdigTeam, error) {
-	endpoint := fmt.Sprintf("%s/platform/v1/teams", apiEndpoint)
+func (c *SysdigClient) FetchTeams(ctx context.Context, filterName string) ([]SysdigTeam, error) {
+	opts := ListOptions{}
 	if filterName != "" {
-		endpoint = fmt.Sprintf("%s?filter=name:%s", endpoint, filterName)
-	}
-
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("building FetchTeams request: %w", err)
-	}
-	// Set your Sysdig token and content type
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("calling FetchTeams: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Handle non-200
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("FetchTeams: status %d, body %s",
-			resp.StatusCode, string(body))
-	}
-
-	// Read the whole body once
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading FetchTeams body: %w", err)
-	}
-
-	// Unmarshal under `data`
-	var wrapper struct {
-		Data []SysdigTeam `json:"data"`
+		opts.Filter = "name:" + filterName
 	}
-	if len(bodyBytes) == 0 {
-		return []SysdigTeam{}, nil
-	}
-	if err := json.Unmarshal(bodyBytes, &wrapper); err != nil {
-		return nil, fmt.Errorf("decoding FetchTeams JSON: %w", err)
-	}
-
-	return wrapper.Data, nil
+	return collect(c.IterTeams(ctx, opts))
 }
 
 // CreateTeam creates a new team in Sysdig without user assignments.
-// It populates Scopes based on provided namespace scopes.
-func CreateTeam(apiEndpoint, token, name, description, product string, namespaces []string) (int64, error) {
-	url := fmt.Sprintf("%s/platform/v1/teams", apiEndpoint)
-	scopes := []Scope{
-		{
-			Type:       "HOST_CONTAINER",
-			Expression: "container", // grants container access
-		},
-		{
-			Type:       "AGENT", //bit different from API documentation: https://app.sysdigcloud.com/apidocs/monitor?_product=SDC#tag/Teams/operation/createTeamV1
-			Expression: BuildFilterExpression(namespaces),
-		}}
-	ui := UISettings{Theme: "#73A1F7"}
-	if product == "monitor" {
-		ui.EntryPoint = &EntryPoint{Module: "Dashboards"}
-	}
+// scopes is usually built with TeamScopes, and settings come from
+// ProductPolicy.Clamp.
+func (c *SysdigClient) CreateTeam(
+	ctx context.Context,
+	name, description, product string,
+	scopes []Scope,
+	settings TeamSettings,
+) (int64, error) {
+	url := fmt.Sprintf("%s/platform/v1/teams", c.endpoints.Platform)
 
 	// TODO: {"type":"unprocessable_entity","message":"Teamless custom events not available in Secure","details":[]}
-	perms := map[string]bool{}
-	if product == "monitor" {
-		perms = map[string]bool{
-			"hasSysdigCaptures":       false,
-			"hasInfrastructureEvents": true,
-			"hasAwsData":              false,
-			"hasRapidResponse":        false,
-			"hasAgentCli":             true,
-			"hasBeaconMetrics":        true,
-		}
-	} else {
-		perms = map[string]bool{
-			"hasSysdigCaptures":       true,
-			"hasInfrastructureEvents": false,
-			"hasAwsData":              false,
-			"hasRapidResponse":        false,
-			"hasAgentCli":             false,
-			"hasBeaconMetrics":        false,
-		}
-	}
 	reqBody := CreateTeamRequest{
 		Name:                      name,
 		Description:               description,
@@ -171,56 +109,57 @@ func CreateTeam(apiEndpoint, token, name, description, product string, namespace
 		CanUseCustomEvents:        true,
 		CanUseSysdigCapture:       false,
 		Scopes:                    scopes,
-		UISettings:                ui,
-		AdditionalTeamPermissions: perms,
+		UISettings:                settings.UI,
+		AdditionalTeamPermissions: settings.Permissions,
 	}
-	return postTeam(url, token, reqBody)
+	return c.postTeam(ctx, url, reqBody)
 }
 
 // shared function to POST a team
-func postTeam(url, token string, body interface{}) (int64, error) {
-	payload, _ := json.Marshal(body)
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+func (c *SysdigClient) postTeam(ctx context.Context, url string, body interface{}) (int64, error) {
+	ctx, cancel := c.withTimeout(ctx, "CreateTeam")
+	defer cancel()
+
+	req, err := c.newRequest(ctx, "POST", url, body)
 	if err != nil {
 		return 0, err
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := c.do("CreateTeam", req)
 	if err != nil {
 		return 0, err
 	}
 	defer resp.Body.Close()
 
-	bodyInfo, _ := io.ReadAll(resp.Body)
-	fmt.Printf("DEBUG: create team response body:\n%s\n", string(bodyInfo))
+	bodyInfo, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("reading CreateTeam response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return 0, newAPIError("CreateTeam", resp, bodyInfo)
+	}
 
 	var created SysdigTeam
 	if err := json.Unmarshal(bodyInfo, &created); err != nil {
-		return 0, fmt.Errorf("parsing CreateUser response JSON: %w", err)
+		return 0, fmt.Errorf("parsing CreateTeam response JSON: %w", err)
 	}
-
-	// fmt.Printf("DEBUG: create team, payload is 11111111: \n %+v\n", created)
-
-	fmt.Printf("Successfully created a team with ID %d and name %s", created.ID, created.Name)
-	// return created.ID, nil
 	return created.ID, nil
 }
 
 // DeleteTeam deletes a team by its ID from Sysdig.
-func DeleteTeam(apiEndpoint, token string, teamID int64) error {
-	url := fmt.Sprintf("%s/platform/v1/teams/%d", apiEndpoint, teamID)
+func (c *SysdigClient) DeleteTeam(ctx context.Context, teamID int64) error {
+	ctx, cancel := c.withTimeout(ctx, "DeleteTeam")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/teams/%d", c.endpoints.Platform, teamID)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := c.newRequest(ctx, "DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create DeleteTeam request: %w", err)
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := client.Do(req)
+	resp, err := c.do("DeleteTeam", req)
 	if err != nil {
 		return fmt.Errorf("failed to execute DeleteTeam request: %w", err)
 	}
@@ -228,57 +167,122 @@ func DeleteTeam(apiEndpoint, token string, teamID int64) error {
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK { // Sysdig API might return 200 or 204 for successful deletion
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("DeleteTeam request failed: status %d, body %s", resp.StatusCode, string(bodyBytes))
+		return newAPIError("DeleteTeam", resp, bodyBytes)
 	}
-
-	fmt.Printf("Successfully deleted team with ID %d\n", teamID)
+	c.forgetTeamToken(teamID)
 	return nil
 }
 
-// we don't update team. as we use membership api to manage access. But just leave it there in case we want to add more function in the future
-// func UpdateTeam(apiEndpoint, token, name, description string, id int64, version int, namespaces []string) (int64, error) {
-// 	url := fmt.Sprintf("%s/platform/v1/teams/%d", apiEndpoint, id)
-// 	reqBody := UpdateTeamRequest{
-// 		CanUseAwsMetrics:    false,
-// 		CanUseCustomEvents:  true,
-// 		CanUseSysdigCapture: false,
-// 		Description:         description,
-// 		Name:                name,
-// 		ID:                  id,
-// 		Version:             version,
-// 		Show:                "container",
-// 		Theme:               "#73A1F7",
-// 		Filter:              BuildFilterExpression(namespaces),
-// 		// UserRoles:           users,
-// 	}
-// 	return putTeam(url, token, reqBody)
-// }
-
-// shared function to PUT a team
-// func putTeam(url, token string, body interface{}) (int64, error) {
-// 	payload, _ := json.Marshal(body)
-// 	client := &http.Client{Timeout: 10 * time.Second}
-// 	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(payload))
-// 	if err != nil {
-// 		return 0, err
-// 	}
-// 	req.Header.Set("Authorization", "Bearer "+token)
-// 	req.Header.Set("Content-Type", "application/json")
-
-// 	resp, err := client.Do(req)
-// 	if err != nil {
-// 		return 0, err
-// 	}
-// 	defer resp.Body.Close()
-
-// 	if resp.StatusCode != http.StatusOK {
-// 		b, _ := io.ReadAll(resp.Body)
-// 		return 0, fmt.Errorf("UpdateTeam: status %d, body %s", resp.StatusCode, string(b))
-// 	}
-
-// 	var updated SysdigTeam
-// 	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
-// 		return 0, err
-// 	}
-// 	return updated.ID, nil
-// }
+// TeamDetails is the full team document returned by GET /platform/v1/teams/{id}.
+// Fields the operator does not manage are kept as returned and sent back
+// unchanged by UpdateTeam.
+type TeamDetails struct {
+	ID                        int64           `json:"id"`
+	Version                   int64           `json:"version"`
+	Name                      string          `json:"name"`
+	Description               string          `json:"description,omitempty"`
+	Product                   string          `json:"product,omitempty"`
+	Scopes                    []Scope         `json:"scopes,omitempty"`
+	UISettings                UISettings      `json:"uiSettings,omitempty"`
+	AdditionalTeamPermissions map[string]bool `json:"additionalTeamPermissions,omitempty"`
+
+	raw map[string]json.RawMessage
+}
+
+// updatableTeamFields are the fields accepted by PUT /platform/v1/teams/{id}.
+var updatableTeamFields = []string{
+	"version", "name", "description", "isDefaultTeam", "standardTeamRole", "customTeamRoleId",
+	"uiSettings", "isAllZones", "zoneIds", "scopes", "additionalTeamPermissions",
+}
+
+// TeamScopes returns the scopes the operator gives a team, with expression
+// as the agent scope, e.g. from BuildScopeExpression.
+func TeamScopes(expression string) []Scope {
+	return []Scope{
+		{
+			Type:       "HOST_CONTAINER",
+			Expression: "container", // grants container access
+		},
+		{
+			Type:       "AGENT", //bit different from API documentation: https://app.sysdigcloud.com/apidocs/monitor?_product=SDC#tag/Teams/operation/createTeamV1
+			Expression: expression,
+		},
+	}
+}
+
+// GetTeam fetches a single team with its scopes and settings.
+func (c *SysdigClient) GetTeam(ctx context.Context, teamID int64) (*TeamDetails, error) {
+	ctx, cancel := c.withTimeout(ctx, "GetTeam")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/teams/%d", c.endpoints.Platform, teamID)
+	req, err := c.newRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do("GetTeam", req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading GetTeam response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("GetTeam", resp, body)
+	}
+
+	var team TeamDetails
+	if err := json.Unmarshal(body, &team); err != nil {
+		return nil, fmt.Errorf("parsing GetTeam response JSON: %w", err)
+	}
+	if err := json.Unmarshal(body, &team.raw); err != nil {
+		return nil, fmt.Errorf("parsing GetTeam response JSON: %w", err)
+	}
+	return &team, nil
+}
+
+// UpdateTeam writes back a team fetched with GetTeam. The version returned by
+// GetTeam is sent along, so a concurrent change makes Sysdig answer 409.
+func (c *SysdigClient) UpdateTeam(ctx context.Context, team *TeamDetails) error {
+	ctx, cancel := c.withTimeout(ctx, "UpdateTeam")
+	defer cancel()
+
+	typed, err := json.Marshal(team)
+	if err != nil {
+		return fmt.Errorf("encoding UpdateTeam request: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(typed, &fields); err != nil {
+		return fmt.Errorf("encoding UpdateTeam request: %w", err)
+	}
+	payload := map[string]json.RawMessage{}
+	for _, name := range updatableTeamFields {
+		if v, ok := fields[name]; ok {
+			payload[name] = v
+		} else if v, ok := team.raw[name]; ok {
+			payload[name] = v
+		}
+	}
+
+	url := fmt.Sprintf("%s/platform/v1/teams/%d", c.endpoints.Platform, team.ID)
+	req, err := c.newRequest(ctx, "PUT", url, payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do("UpdateTeam", req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError("UpdateTeam", resp, body)
+	}
+	return nil
+}
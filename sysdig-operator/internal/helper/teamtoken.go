@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// DefaultTeamTokenRole is the role the team token user is given when it has
+// to be added to a team. Alerts and notification channels need more than
+// ROLE_TEAM_STANDARD.
+const DefaultTeamTokenRole = "ROLE_TEAM_EDIT"
+
+// TeamTokenConfig names the platform service account whose team-scoped
+// tokens are used for dashboard, alert and notification channel requests.
+// Those APIs act on the team of the token, not on the teamId in the body.
+type TeamTokenConfig struct {
+	// User is the username (email) of the service account.
+	User string
+	// Role is the team role it is added with when missing from a team.
+	// Empty means DefaultTeamTokenRole.
+	Role string
+}
+
+// teamTokens caches the team-scoped tokens of one user, keyed by team ID.
+type teamTokens struct {
+	cfg TeamTokenConfig
+
+	mu     sync.Mutex
+	tokens map[int64]string
+}
+
+// teamKey is the context key of the team a request runs in.
+type teamKey struct{}
+
+// WithTeam returns a context whose requests run in the given team, using a
+// token of the configured team token user. Team 0 means the client's own
+// token. Without WithTeamTokens the team is ignored.
+func WithTeam(ctx context.Context, teamID int64) context.Context {
+	return context.WithValue(ctx, teamKey{}, teamID)
+}
+
+// teamFromContext returns the team set by WithTeam, or 0.
+func teamFromContext(ctx context.Context) int64 {
+	teamID, _ := ctx.Value(teamKey{}).(int64)
+	return teamID
+}
+
+// inTeam scopes ctx to teamID unless teamID is 0. Writes use it with the
+// teamId of their payload.
+func inTeam(ctx context.Context, teamID int64) context.Context {
+	if teamID == 0 {
+		return ctx
+	}
+	return WithTeam(ctx, teamID)
+}
+
+// WithTeamTokens makes requests scoped by WithTeam use a token of cfg.User in
+// that team. An empty user turns team tokens off.
+func (c *SysdigClient) WithTeamTokens(cfg TeamTokenConfig) *SysdigClient {
+	if cfg.User == "" {
+		c.teamTokens = nil
+		return c
+	}
+	if cfg.Role == "" {
+		cfg.Role = DefaultTeamTokenRole
+	}
+	c.teamTokens = &teamTokens{cfg: cfg, tokens: map[int64]string{}}
+	return c
+}
+
+// requestToken returns the token for a request made with ctx.
+func (c *SysdigClient) requestToken(ctx context.Context) (string, error) {
+	teamID := teamFromContext(ctx)
+	if teamID == 0 || c.teamTokens == nil {
+		return c.token, nil
+	}
+	return c.TeamToken(ctx, teamID)
+}
+
+// TeamToken returns the token of the team token user in teamID. Tokens are
+// cached until Sysdig rejects them. A user that is not yet a member of the
+// team is added to it with the configured role.
+func (c *SysdigClient) TeamToken(ctx context.Context, teamID int64) (string, error) {
+	if c.teamTokens == nil {
+		return "", fmt.Errorf("team tokens are not configured")
+	}
+	t := c.teamTokens
+	t.mu.Lock()
+	token, ok := t.tokens[teamID]
+	t.mu.Unlock()
+	if ok {
+		return token, nil
+	}
+
+	// Fetching and joining use the client's own token.
+	ctx = WithTeam(ctx, 0)
+	token, err := c.fetchTeamToken(ctx, t.cfg.User, teamID)
+	if IsNotFound(err) || IsBadRequest(err) || hasStatus(err, http.StatusForbidden) {
+		if err := c.joinTeam(ctx, teamID); err != nil {
+			return "", err
+		}
+		token, err = c.fetchTeamToken(ctx, t.cfg.User, teamID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	t.tokens[teamID] = token
+	t.mu.Unlock()
+	return token, nil
+}
+
+// forgetTeamToken drops the cached token of teamID, e.g. after a 401.
+func (c *SysdigClient) forgetTeamToken(teamID int64) {
+	if c.teamTokens == nil || teamID == 0 {
+		return
+	}
+	c.teamTokens.mu.Lock()
+	delete(c.teamTokens.tokens, teamID)
+	c.teamTokens.mu.Unlock()
+}
+
+// fetchTeamToken exchanges the client's token for the token of user in teamID.
+func (c *SysdigClient) fetchTeamToken(ctx context.Context, user string, teamID int64) (string, error) {
+	ctx, cancel := c.withTimeout(ctx, "FetchTeamToken")
+	defer cancel()
+
+	endpoint := fmt.Sprintf("%s/api/token/%s/%d", c.endpoints.Monitor, url.PathEscape(user), teamID)
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.do("FetchTeamToken", req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading FetchTeamToken response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError("FetchTeamToken", resp, body)
+	}
+
+	var out struct {
+		Token struct {
+			Key string `json:"key"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("parsing FetchTeamToken response JSON: %w", err)
+	}
+	if out.Token.Key == "" {
+		return "", fmt.Errorf("FetchTeamToken returned no token for %s in team %d", user, teamID)
+	}
+	return out.Token.Key, nil
+}
+
+// joinTeam adds the team token user to teamID.
+func (c *SysdigClient) joinTeam(ctx context.Context, teamID int64) error {
+	cfg := c.teamTokens.cfg
+	user, err := c.FindUserByEmail(ctx, cfg.User)
+	if err != nil {
+		return fmt.Errorf("find team token user %q: %w", cfg.User, err)
+	}
+	if user == nil {
+		return fmt.Errorf("team token user %q does not exist in Sysdig", cfg.User)
+	}
+	if _, err := c.SaveMembership(ctx, teamID, user.ID, cfg.Role); err != nil {
+		return fmt.Errorf("add team token user %q to team %d: %w", cfg.User, teamID, err)
+	}
+	return nil
+}
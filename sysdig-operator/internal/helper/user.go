@@ -2,12 +2,12 @@
 package helpers
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
-	"time"
 )
 
 // SysdigUser represents one user object from GET /platform/v1/users
@@ -24,11 +24,6 @@ type TeamUserRole struct {
 	UserID int64
 }
 
-// UsersResponse wraps the list returned by Sysdig under "data"
-type UsersResponse struct {
-	Data []SysdigUser `json:"data"`
-}
-
 // CreateUserRequest is the payload you POST when creating a new user
 type CreateUserRequest struct {
 	Email string `json:"email"`
@@ -48,58 +43,38 @@ type CreateUserResponse struct {
 	Version     int     `json:"version,omitempty"`
 }
 
+// IterUsers streams users from GET /platform/v1/users, walking every page.
+func (c *SysdigClient) IterUsers(ctx context.Context, opts ListOptions) iter.Seq2[SysdigUser, error] {
+	endpoint := fmt.Sprintf("%s/platform/v1/users", c.endpoints.Platform)
+	return paginate[SysdigUser](ctx, c, "FetchUsers", endpoint, opts)
+}
+
 // FetchUsers calls GET /platform/v1/users and applies an optional email filter.
 // If filterEmail is non-empty, the request uses the 'filter=email:<value>' query parameter.
-func FetchUsers(apiEndpoint, token, filterEmail string) ([]SysdigUser, error) {
-	// Build base URL
-	endpoint := fmt.Sprintf("%s/platform/v1/users", apiEndpoint)
-	// Append filter parameter if provided
+func (c *SysdigClient) FetchUsers(ctx context.Context, filterEmail string) ([]SysdigUser, error) {
+	opts := ListOptions{}
 	if filterEmail != "" {
-		endpoint = fmt.Sprintf("%s?filter=email:%s", endpoint, filterEmail)
+		opts.Filter = "email:" + filterEmail
 	}
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("request error: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("FetchUsers: status %d, body %s", resp.StatusCode, string(body))
-	}
-
-	// Decode the wrapper and return the inner slice
-	var wrapper UsersResponse
-	if err := json.NewDecoder(resp.Body).Decode(&wrapper); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
-	}
-	return wrapper.Data, nil
+	return collect(c.IterUsers(ctx, opts))
 }
 
-// CreateUser creates a new user and returns its ID
-func CreateUser(apiEndpoint, token, email, role string) (int64, error) {
-	url := fmt.Sprintf("%s/platform/v1/users", apiEndpoint)
+// CreateUser creates a new user and returns its ID, or the ID of the user
+// that already has the email.
+func (c *SysdigClient) CreateUser(ctx context.Context, email, role string) (int64, error) {
+	parent := ctx
+	ctx, cancel := c.withTimeout(ctx, "CreateUser")
+	defer cancel()
+
+	url := fmt.Sprintf("%s/platform/v1/users", c.endpoints.Platform)
 	payload := CreateUserRequest{Email: email, Role: role}
-	body, _ := json.Marshal(payload)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := c.newRequest(ctx, "POST", url, payload)
 	if err != nil {
 		return 0, err
 	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := c.do("CreateUser", req)
 	if err != nil {
 		return 0, err
 	}
@@ -110,16 +85,27 @@ func CreateUser(apiEndpoint, token, email, role string) (int64, error) {
 		return 0, fmt.Errorf("reading response body: %w", err)
 	}
 
+	if resp.StatusCode == http.StatusConflict {
+		// The email is taken, e.g. by an earlier attempt whose response was
+		// lost and which was retried. The existing user is the one we wanted.
+		conflict := newAPIError("CreateUser", resp, bodyInfo)
+		user, err := c.FindUserByEmail(parent, email)
+		if err != nil {
+			return 0, fmt.Errorf("%w; looking up the existing user: %v", conflict, err)
+		}
+		if user == nil {
+			return 0, conflict
+		}
+		return user.ID, nil
+	}
 	if resp.StatusCode != http.StatusCreated {
-		return 0, fmt.Errorf("CreateUser: status %d, body %s",
-			resp.StatusCode, string(bodyInfo))
+		return 0, newAPIError("CreateUser", resp, bodyInfo)
 	}
 
 	var cr CreateUserResponse
 	if err := json.Unmarshal(bodyInfo, &cr); err != nil {
 		return 0, fmt.Errorf("parsing CreateUser response JSON: %w", err)
 	}
-	fmt.Printf("I want to check what is comeback payload: \n %+v\n", cr)
 
 	//Return the new user ID:
 	return cr.ID, nil